@@ -14,10 +14,16 @@ import (
 	"os"
 	"supmap-users/internal/api"
 	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
 	"supmap-users/internal/repository"
 	"supmap-users/internal/services"
+	"supmap-users/internal/services/auth"
 	rediss "supmap-users/internal/services/redis"
 	"supmap-users/internal/services/scheduler"
+	"supmap-users/internal/services/storage"
+	"supmap-users/internal/services/stream"
+	"supmap-users/internal/services/tiles"
+	"supmap-users/internal/services/users"
 	"supmap-users/migrations"
 	"time"
 )
@@ -75,6 +81,9 @@ func main() {
 
 	// Create users repository
 	incidents := repository.NewIncidents(bunDB, logger)
+	if err := incidents.DetectPostGIS(context.Background()); err != nil {
+		log.Fatal(fmt.Errorf("failed to detect postgis: %w", err))
+	}
 	interactions := repository.NewInteractions(bunDB, logger)
 
 	// Redis service
@@ -88,16 +97,45 @@ func main() {
 	redisService := rediss.NewRedis(rdb, logger)
 	redisService.Run(context.Background())
 
+	// Filtre des mots interdits dans les descriptions des incidents
+	profanity, err := helpers.NewProfanityFilter(conf.ProfanityWords, conf.ProfanityWordsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Stockage des photos jointes aux incidents
+	photoStorage, err := storage.New(conf)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	// Create users service
-	service := services.NewService(logger, conf, incidents, interactions, redisService)
+	service := services.NewService(logger, conf, incidents, interactions, redisService, profanity, photoStorage)
 
 	// Taches actives pour l'auto modération des incidents
 	tasks := scheduler.NewScheduler(time.Minute, conf, incidents, interactions, redisService, logger)
 	tasks.Run()
 	defer tasks.Stop()
 
+	// Diffusion temps réel des incidents aux clients
+	hub := stream.NewHub(conf, redisService, logger)
+	hub.Run(context.Background())
+
+	// Résolution des utilisateurs auprès du service users
+	usersResolver := users.NewResolver(conf, redisService, logger)
+
+	// Authentification des requêtes auprès du service users
+	authenticator := auth.NewAuthenticator(conf, redisService, logger)
+	if err := authenticator.Run(context.Background()); err != nil {
+		log.Fatal(err)
+	}
+
+	// Cache des tuiles vectorielles, invalidé par les événements des incidents
+	tilesCache := tiles.NewCache(conf, redisService, logger)
+	tilesCache.Run(context.Background())
+
 	// Create the HTTP server
-	server := api.NewServer(conf, logger, service)
+	server := api.NewServer(conf, logger, service, hub, usersResolver, authenticator, tilesCache)
 	if err := server.Start(); err != nil {
 		log.Fatal(err)
 	}
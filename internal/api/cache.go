@@ -0,0 +1,116 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"supmap-users/internal/models"
+	"time"
+)
+
+// Politiques de cache des réponses. Les types d'incidents changent rarement et peuvent être
+// conservés quelques minutes, les incidents doivent être revalidés à chaque requête : l'ETag
+// permet alors de répondre 304 sans renvoyer le corps si rien n'a changé. Le contenu d'une
+// photo ne change jamais pour une clé donnée.
+const (
+	cacheTypes     = "public, max-age=300"
+	cacheIncidents = "public, no-cache"
+	cachePhotos    = "public, max-age=31536000, immutable"
+)
+
+// notModified ajoute les headers de cache à la réponse et indique si la version détenue
+// par le client est à jour, auquel cas la réponse 304 est déjà écrite.
+// lastModified est ignoré lorsqu'il vaut zéro.
+func notModified(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time, cacheControl string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+
+	// If-Modified-Since n'est évalué qu'en l'absence de If-None-Match (RFC 9110, section 13.2.2)
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(since) {
+		return false
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatches compare un header If-None-Match à l'ETag de la ressource.
+// La comparaison est faible comme l'impose la RFC pour ce header.
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// incidentsETag calcule un ETag fort à partir des IDs des incidents, de leur date de
+// modification la plus récente et de leur type, dont les seuils influent sur le statut.
+// variant distingue les représentations d'un même résultat (paramètres, format).
+func incidentsETag(variant string, incidents ...*models.Incident) (etag string, lastModified time.Time) {
+	h := sha256.New()
+	_, _ = fmt.Fprint(h, variant)
+
+	for _, incident := range incidents {
+		_, _ = fmt.Fprintf(h, "|%d", incident.ID)
+		if incident.Type != nil {
+			writeType(h, incident.Type)
+		}
+		if incident.UpdatedAt.After(lastModified) {
+			lastModified = incident.UpdatedAt
+		}
+	}
+	_, _ = fmt.Fprintf(h, "|%d", lastModified.UnixNano())
+
+	return formatETag(h), lastModified
+}
+
+// typesETag calcule un ETag fort à partir du contenu des types d'incidents
+func typesETag(types ...*models.Type) string {
+	h := sha256.New()
+	for _, t := range types {
+		writeType(h, t)
+	}
+	return formatETag(h)
+}
+
+func writeType(h hash.Hash, t *models.Type) {
+	_, _ = fmt.Fprintf(h, "|%d;%s;%s;%d;%d;%d;%d;%t;%v",
+		t.ID, t.Name, t.Description,
+		t.LifetimeWithoutConfirmation, t.NegativeReportsThreshold,
+		t.GlobalLifetime, t.PositiveReportsThreshold,
+		t.NeedRecalculation, t.DisabledAt != nil,
+	)
+	// json.Marshal trie les clés, l'encodage du schéma est donc stable
+	schema, _ := json.Marshal(t.AttributesSchema)
+	_, _ = h.Write(schema)
+}
+
+// contentETag calcule un ETag fort à partir du contenu binaire de la réponse
+func contentETag(content []byte) string {
+	h := sha256.New()
+	_, _ = h.Write(content)
+	return formatETag(h)
+}
+
+func formatETag(h hash.Hash) string {
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// representationVariant identifie la représentation demandée : paramètres de la requête et format
+func representationVariant(r *http.Request) string {
+	return fmt.Sprintf("%s|geojson=%t", r.URL.RawQuery, wantsGeoJSON(r))
+}
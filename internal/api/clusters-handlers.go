@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"github.com/matheodrd/httphelper/handler"
+	"net/http"
+	"strconv"
+	"strings"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services"
+)
+
+// GetIncidentClusters godoc
+// @Summary Regrouper les incidents d'une zone pour les niveaux de zoom éloignés
+// @Description Regroupe les incidents en cours de la zone en clusters calculés côté serveur, dont la taille dépend du niveau de zoom de la carte.
+// @Description Chaque cluster contient son barycentre, le nombre d'incidents, leur répartition par type et l'emprise des incidents qu'il regroupe.
+// @Description Au-delà du zoom configuré (CLUSTERS_MAX_ZOOM), les incidents sont retournés individuellement dans incidents, comme par /incidents/bbox, et clusters est vide.
+// @Description La zone est passée dans le paramètre bbox (min_lon,min_lat,max_lon,max_lat) ou dans les paramètres min_lat, min_lon, max_lat et max_lon.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Param bbox query string false "Zone au format min_lon,min_lat,max_lon,max_lat"
+// @Param min_lat query number false "Latitude minimale de la zone, si bbox n'est pas fourni"
+// @Param min_lon query number false "Longitude minimale (ouest) de la zone, si bbox n'est pas fourni"
+// @Param max_lat query number false "Latitude maximale de la zone, si bbox n'est pas fourni"
+// @Param max_lon query number false "Longitude maximale (est) de la zone, si bbox n'est pas fourni"
+// @Param zoom query integer true "Niveau de zoom de la carte (0 à 22)"
+// @Param type_id query integer false "Filtrer par type d'incident"
+// @Param include query string false "Données additionnelles des incidents retournés individuellement : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Success 200 {object} dto.ClustersResultDTO "Clusters ou incidents de la zone"
+// @Failure 400 {object} ErrorResponse "Paramètres invalides ou manquants"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/clusters [get]
+func (s *Server) GetIncidentClusters() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		box, err := decodeBboxParam(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		zoom, err := decodeParamAs[int64](r, "zoom")
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		incidentType, err := decodeOptionalParamAs[int64](r, "type_id")
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		clusters, err := s.service.GetIncidentClusters(r.Context(), incidentType, box, int(zoom))
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		result := &dto.ClustersResultDTO{
+			Zoom:      int(zoom),
+			Clusters:  make([]dto.ClusterDTO, len(clusters.Clusters)),
+			Truncated: clusters.Truncated,
+		}
+		for i, cluster := range clusters.Clusters {
+			result.Clusters[i] = *dto.ClusterToDTO(&cluster)
+		}
+
+		if len(clusters.Incidents) > 0 {
+			include := decodeIncludeParam(r)
+			ids := dto.UserIDs{}
+			for i := range clusters.Incidents {
+				ids.AddIncident(&clusters.Incidents[i], include)
+			}
+			users := s.users.Resolve(r.Context(), ids)
+
+			result.Incidents = make([]dto.IncidentDTO, len(clusters.Incidents))
+			for i, incident := range clusters.Incidents {
+				result.Incidents[i] = *dto.IncidentToDTO(&incident, include, users)
+			}
+		}
+
+		return encode(result, http.StatusOK, w)
+	})
+}
+
+// decodeBboxParam lit la zone dans le paramètre bbox (min_lon,min_lat,max_lon,max_lat),
+// dans l'ordre des bbox GeoJSON, ou à défaut dans les paramètres min_lat, min_lon, max_lat et max_lon
+func decodeBboxParam(r *http.Request) (*helpers.BoundingBox, error) {
+	value := r.URL.Query().Get("bbox")
+	if value == "" {
+		return decodeBoundingBox(r)
+	}
+
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+	}
+
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+		}
+		coordinates[i] = coordinate
+	}
+
+	return &helpers.BoundingBox{
+		MinLon: coordinates[0],
+		MinLat: coordinates[1],
+		MaxLon: coordinates[2],
+		MaxLat: coordinates[3],
+	}, nil
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/go-playground/validator/v10"
@@ -10,9 +11,11 @@ import (
 	"strconv"
 	"strings"
 	"supmap-users/internal/api/validations"
+	"supmap-users/internal/helpers"
 	"supmap-users/internal/models"
 	"supmap-users/internal/models/dto"
 	"supmap-users/internal/services"
+	"time"
 )
 
 type InternalErrorResponse struct {
@@ -26,15 +29,22 @@ type ErrorResponse struct {
 // GetAllInRadius godoc
 // @Summary Récupérer les incidents dans un rayon donné
 // @Description Récupère tous les incidents non supprimés situés dans un rayon donné à partir des coordonnées passées en paramètre.
-// @Description Cette requête est très couteuse car elle effectue de nombreux appels à la base de données pour charger l'entièreté des données. Elle est à utiliser avec précautions !
+// @Description Les types et interactions des incidents sont chargés en un nombre constant de requêtes, quel que soit le nombre d'incidents trouvés.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
 // @Tags incidents
 // @Accept json
 // @Produce json
+// @Produce application/geo+json
 // @Param lat query number true "Latitude du centre de la recherche"
 // @Param lon query number true "Longitude du centre de la recherche"
 // @Param radius query integer true "Rayon de recherche en mètres"
+// @Param heading query number false "Cap du véhicule en degrés (0 à 360), seuls les incidents concernant son sens de circulation sont retournés"
 // @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
 // @Success 200 {array} dto.IncidentWithDistanceDTO "Liste des incidents dans le rayon, avec distance calculée"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
 // @Failure 400 {object} ErrorResponse "Paramètres invalides ou manquants"
 // @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
 // @Router /incidents [get]
@@ -59,35 +69,299 @@ func (s *Server) GetAllInRadius() http.HandlerFunc {
 
 		incidentType, _ := decodeParamAs[*int64](r, "type_id")
 
-		incidents, err := s.service.FindIncidentsInRadius(r.Context(), incidentType, latitude, longitude, radius)
+		var heading *float64
+		if r.URL.Query().Has("heading") {
+			value, err := decodeParamAs[float64](r, "heading")
+			if err != nil || value < 0 || value >= 360 {
+				return encode(&ErrorResponse{Error: "heading must be between 0 and 360"}, http.StatusBadRequest, w)
+			}
+			heading = &value
+		}
+
+		incidents, err := s.service.FindIncidentsInRadius(r.Context(), incidentType, latitude, longitude, radius, heading)
 		if err != nil {
 			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
 				return encode(ewc, ewc.Code, w)
 			}
+			return err
 		}
 
+		results := make([]*models.Incident, len(incidents))
+		for i := range incidents {
+			results[i] = &incidents[i].Incident
+		}
+		w.Header().Set("Vary", "Accept")
+		if etag, _ := incidentsETag(representationVariant(r), results...); notModified(w, r, etag, time.Time{}, cacheIncidents) {
+			return nil
+		}
+
+		ids := dto.UserIDs{}
+		for _, incident := range results {
+			ids.AddIncident(incident, include)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
 		var incidentsDTOs = make([]dto.IncidentWithDistanceDTO, len(incidents))
 		for i, incident := range incidents {
-			incidentsDTOs[i] = *dto.IncidentWithDistanceToDTO(&incident, include)
+			incidentsDTOs[i] = *dto.IncidentWithDistanceToDTO(&incident, include, users)
+		}
+
+		if wantsGeoJSON(r) {
+			return encodeGeoJSON(dto.ToFeatureCollection(incidentsDTOs), http.StatusOK, w)
 		}
 
 		return encode(incidentsDTOs, http.StatusOK, w)
 	})
 }
 
+// GetAllInBoundingBox godoc
+// @Summary Récupérer les incidents d'une zone rectangulaire
+// @Description Récupère les incidents non supprimés situés dans la zone délimitée par les coordonnées passées en paramètre (viewport de la carte).
+// @Description Si min_lon est supérieur à max_lon, la zone traverse l'antiméridien.
+// @Description Le nombre d'incidents retournés est plafonné, le champ truncated indique que la zone contient d'autres incidents.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Produce application/geo+json
+// @Param min_lat query number true "Latitude minimale de la zone"
+// @Param min_lon query number true "Longitude minimale (ouest) de la zone"
+// @Param max_lat query number true "Latitude maximale de la zone"
+// @Param max_lon query number true "Longitude maximale (est) de la zone"
+// @Param type_id query integer false "Filtrer par type d'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
+// @Success 200 {object} dto.BoundingBoxResultDTO "Incidents de la zone, du plus récent au plus ancien"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
+// @Failure 400 {object} ErrorResponse "Paramètres invalides ou manquants"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/bbox [get]
+func (s *Server) GetAllInBoundingBox() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		box, err := decodeBoundingBox(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		incidentType, err := decodeOptionalParamAs[int64](r, "type_id")
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		incidents, truncated, err := s.service.FindIncidentsInBoundingBox(r.Context(), incidentType, box)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		return s.encodeBoundedIncidents(w, r, incidents, truncated)
+	})
+}
+
+// GetAllInGeohash godoc
+// @Summary Récupérer les incidents d'une cellule geohash
+// @Description Récupère les incidents non supprimés dont le geohash commence par le préfixe passé en paramètre, c'est-à-dire situés dans la cellule correspondante.
+// @Description Le nombre d'incidents retournés est plafonné comme pour les zones rectangulaires, le champ truncated indique que la cellule contient d'autres incidents.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Produce application/geo+json
+// @Param prefix path string true "Geohash de la cellule, de 1 à 9 caractères"
+// @Param type_id query integer false "Filtrer par type d'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
+// @Success 200 {object} dto.BoundingBoxResultDTO "Incidents de la cellule, du plus récent au plus ancien"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
+// @Failure 400 {object} services.ErrorWithCode "Geohash ou type_id invalide"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/geohash/{prefix} [get]
+func (s *Server) GetAllInGeohash() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		incidentType, err := decodeOptionalParamAs[int64](r, "type_id")
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		incidents, truncated, err := s.service.FindIncidentsInGeohash(r.Context(), incidentType, r.PathValue("prefix"))
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		return s.encodeBoundedIncidents(w, r, incidents, truncated)
+	})
+}
+
+// encodeBoundedIncidents écrit une liste d'incidents plafonnée, au format JSON ou GeoJSON
+func (s *Server) encodeBoundedIncidents(w http.ResponseWriter, r *http.Request, incidents []models.Incident, truncated bool) error {
+	results := make([]*models.Incident, len(incidents))
+	for i := range incidents {
+		results[i] = &incidents[i]
+	}
+	w.Header().Set("Vary", "Accept")
+	if etag, _ := incidentsETag(representationVariant(r)+fmt.Sprintf("|truncated=%t", truncated), results...); notModified(w, r, etag, time.Time{}, cacheIncidents) {
+		return nil
+	}
+
+	include := decodeIncludeParam(r)
+	ids := dto.UserIDs{}
+	for _, incident := range results {
+		ids.AddIncident(incident, include)
+	}
+	users := s.users.Resolve(r.Context(), ids)
+
+	var incidentsDTOs = make([]dto.IncidentDTO, len(incidents))
+	for i, incident := range incidents {
+		incidentsDTOs[i] = *dto.IncidentToDTO(&incident, include, users)
+	}
+
+	if wantsGeoJSON(r) {
+		collection := dto.ToFeatureCollection(incidentsDTOs)
+		collection.Truncated = &truncated
+		return encodeGeoJSON(collection, http.StatusOK, w)
+	}
+
+	return encode(&dto.BoundingBoxResultDTO{
+		Incidents: incidentsDTOs,
+		Truncated: truncated,
+	}, http.StatusOK, w)
+}
+
+// GetAllAlongRoute godoc
+// @Summary Récupérer les incidents le long d'un itinéraire
+// @Description Récupère les incidents non supprimés situés à moins de buffer mètres d'un itinéraire, triés selon leur position le long de celui-ci.
+// @Description L'itinéraire est fourni soit sous forme de polyline encodée (format Google, précision 5 ou 6), soit sous forme de LineString GeoJSON.
+// @Description need_recalculation indique qu'au moins un incident nécessite de recalculer l'itinéraire.
+// @Description Avec same_direction, les incidents signalés dans le sens opposé à celui de l'itinéraire sont ignorés, les incidents sans cap ou dans les deux sens sont conservés.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Produce application/geo+json
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param body body validations.RouteIncidentsValidator true "Itinéraire et largeur du corridor en mètres"
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Success 200 {object} dto.RouteIncidentsDTO "Incidents le long de l'itinéraire"
+// @Failure 400 {object} services.ErrorWithCode "Itinéraire invalide"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /internal/incidents/route [post]
+func (s *Server) GetAllAlongRoute() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		body, err := handler.Decode[validations.RouteIncidentsValidator](r)
+		if err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		incidents, err := s.service.FindIncidentsAlongRoute(r.Context(), &body)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		include := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		for i := range incidents {
+			ids.AddIncident(&incidents[i].Incident, include)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
+		result := dto.RouteIncidentsDTO{
+			Incidents: make([]dto.IncidentOnRouteDTO, len(incidents)),
+		}
+		for i, incident := range incidents {
+			result.Incidents[i] = *dto.IncidentOnRouteToDTO(&incident, include, users)
+			result.NeedRecalculation = result.NeedRecalculation || result.Incidents[i].NeedRecalculation
+		}
+
+		if wantsGeoJSON(r) {
+			collection := dto.ToFeatureCollection(result.Incidents)
+			collection.NeedRecalculation = &result.NeedRecalculation
+			return encodeGeoJSON(collection, http.StatusOK, w)
+		}
+
+		return encode(result, http.StatusOK, w)
+	})
+}
+
+// GetIncidentById godoc
+// @Summary Récupérer un incident par son ID
+// @Description Récupère un incident, qu'il soit en cours ou expiré, à partir de son ID.
+// @Description Le champ status indique si l'incident est actif, certifié ou expiré.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
+// @Success 200 {object} dto.IncidentDTO "Incident trouvé"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
+// @Failure 400 {object} ErrorResponse "ID de l'incident invalide"
+// @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/{id} [get]
+func (s *Server) GetIncidentById() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident id"}, http.StatusBadRequest, w)
+		}
+
+		incident, err := s.service.FindIncidentById(r.Context(), id)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		if etag, lastModified := incidentsETag(representationVariant(r), incident); notModified(w, r, etag, lastModified, cacheIncidents) {
+			return nil
+		}
+
+		interactionState := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		ids.AddIncident(incident, interactionState)
+		incidentDTO := dto.IncidentToDTO(incident, interactionState, s.users.Resolve(r.Context(), ids))
+		return encode(incidentDTO, http.StatusOK, w)
+	})
+}
+
 // CreateIncident godoc
 // @Summary Créer un incident
 // @Description Crée un nouvel incident si aucun n'existe dans la zone (<100m). Sinon, ajoute une interaction à l'incident existant.
+// @Description La description optionnelle (280 caractères au plus) est nettoyée et ses mots interdits sont masqués. Elle est ignorée lorsque le signalement devient une interaction.
+// @Description Le cap optionnel (heading, en degrés) indique le sens de circulation concerné, both_directions un incident touchant les deux sens. Un signalement n'est rattaché qu'à un incident du même sens, un incident ou un signalement dans les deux sens étant compatible avec tous les caps.
+// @Description La gravité (severity) vaut moderate par défaut. Si le signalement devient une interaction, il rapproche d'un niveau la gravité de l'incident existant de celle signalée.
+// @Description Les attributs sont validés avec le schéma JSON du type d'incident (attributes_schema), un type sans schéma n'accepte pas d'attributs.
+// @Description Des photos JPEG ou PNG peuvent être jointes en envoyant la requête en multipart/form-data : le champ data contient alors le JSON de l'incident et chaque champ photos un fichier.
+// @Description Les photos sont réencodées en JPEG sans leurs métadonnées EXIF (dont la position GPS) et une miniature est générée.
 // @Tags incidents
 // @Security BearerAuth
-// @Accept json
+// @Accept json,mpfd
 // @Produce json
 // @Param include query string false "Inclure des données additionnelles : 'interactions' pour les interactions complètes ou 'summary' pour un résumé" Enums(interactions,summary)
-// @Param incident body validations.CreateIncidentValidator true "Données nécessaires pour créer un incident"
+// @Param incident body validations.CreateIncidentValidator true "Données nécessaires pour créer un incident, dans le champ data en multipart/form-data"
+// @Param photos formData file false "Photo jointe à l'incident (PHOTOS_MAX_COUNT au plus)"
 // @Success 200 {object} dto.IncidentDTO "Incident créé avec succès"
 // @Success 202 {object} dto.IncidentDTO "Interaction ajoutée à un incident existant"
 // @Failure 400 {object} services.ErrorWithCode "Type d'incident inexistant ou données invalides"
 // @Failure 401 {object} nil "Utilisateur non authentifié"
+// @Failure 413 {object} services.ErrorWithCode "Photo trop volumineuse"
 // @Failure 429 {object} services.ErrorWithCode "Signalement trop fréquent ou interaction déjà existante"
 // @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
 // @Router /incidents [post]
@@ -98,21 +372,28 @@ func (s *Server) CreateIncident() http.HandlerFunc {
 			return encodeNil(http.StatusUnauthorized, w)
 		}
 
-		body, err := handler.Decode[validations.CreateIncidentValidator](r)
+		body, uploads, err := decodeWithPhotos[validations.CreateIncidentValidator](w, r, s.Config)
 		if err != nil {
-			return buildValidationErrors(err, w)
+			return buildDecodeErrors(err, w)
 		}
 
 		interactionState := decodeIncludeParam(r)
-		incident, err := s.service.CreateIncident(r.Context(), user, &body)
+		incident, err := s.service.CreateIncident(r.Context(), user, &body, uploads)
 		if err != nil {
 			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
 				return encode(ewc, ewc.Code, w)
 			}
 
+			var ve *validations.ValidationError
+			if errors.As(err, &ve) {
+				return encode(ve, http.StatusBadRequest, w)
+			}
+
 			if ewb := services.DecodeErrorWithBody[models.Incident](err); ewb != nil {
 				if incident, ok := ewb.GetBody().(models.Incident); ok {
-					incidentDTO := dto.IncidentToDTO(&incident, interactionState)
+					ids := dto.UserIDs{}
+					ids.AddIncident(&incident, interactionState)
+					incidentDTO := dto.IncidentToDTO(&incident, interactionState, s.users.Resolve(r.Context(), ids))
 					return encode(incidentDTO, ewb.Code, w)
 				}
 			}
@@ -120,22 +401,151 @@ func (s *Server) CreateIncident() http.HandlerFunc {
 			return err
 		}
 
-		incidentDTO := *dto.IncidentToDTO(incident, interactionState)
+		ids := dto.UserIDs{}
+		ids.AddIncident(incident, interactionState)
+		incidentDTO := *dto.IncidentToDTO(incident, interactionState, s.users.Resolve(r.Context(), ids))
+
+		return encode(incidentDTO, http.StatusOK, w)
+	})
+}
+
+// UpdateIncidentDescription godoc
+// @Summary Modifier la description d'un incident
+// @Description Permet à l'auteur d'un signalement de modifier ou de retirer sa description pendant la période suivant la création de l'incident (DESCRIPTION_EDIT_WINDOW).
+// @Description La description est nettoyée (balises HTML, caractères de contrôle, espaces superflus) et les mots interdits sont masqués. Un message 'updated' est publié dans Redis.
+// @Tags incidents
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param body body validations.UpdateIncidentDescriptionValidator true "Nouvelle description, null ou vide pour la retirer"
+// @Success 200 {object} dto.IncidentDTO "Incident mis à jour"
+// @Failure 400 {object} ErrorResponse "ID de l'incident ou données invalides"
+// @Failure 401 {object} nil "Utilisateur non authentifié"
+// @Failure 403 {object} services.ErrorWithCode "Utilisateur qui n'est pas l'auteur du signalement ou période de modification dépassée"
+// @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
+// @Failure 423 {object} services.ErrorWithCode "Incident expiré"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/{id} [patch]
+func (s *Server) UpdateIncidentDescription() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		user, ok := r.Context().Value("user").(*dto.PartialUserDTO)
+		if !ok {
+			return encodeNil(http.StatusUnauthorized, w)
+		}
+
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident id"}, http.StatusBadRequest, w)
+		}
+
+		body, err := handler.Decode[validations.UpdateIncidentDescriptionValidator](r)
+		if err != nil {
+			return buildValidationErrors(err, w)
+		}
 
+		incident, err := s.service.UpdateIncidentDescription(r.Context(), user, id, &body)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		include := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		ids.AddIncident(incident, include)
+		incidentDTO := dto.IncidentToDTO(incident, include, s.users.Resolve(r.Context(), ids))
 		return encode(incidentDTO, http.StatusOK, w)
 	})
 }
 
+// GetIncidentChanges godoc
+// @Summary Synchronisation différentielle des incidents d'une zone
+// @Description Récupère les incidents de la zone créés, modifiés ou expirés depuis le jeton since, ainsi qu'un nouveau jeton à utiliser pour la synchronisation suivante.
+// @Description Sans jeton, tous les incidents en cours de la zone sont retournés dans created.
+// @Description Le nombre d'incidents est plafonné, has_more indique qu'il faut rappeler immédiatement l'endpoint avec le nouveau jeton.
+// @Description Un même incident peut être renvoyé par deux synchronisations successives, le client doit appliquer les modifications de manière idempotente.
+// @Tags incidents
+// @Accept json
+// @Produce json
+// @Param since query string false "Jeton retourné par la synchronisation précédente"
+// @Param min_lat query number true "Latitude minimale de la zone"
+// @Param min_lon query number true "Longitude minimale (ouest) de la zone"
+// @Param max_lat query number true "Latitude maximale de la zone"
+// @Param max_lon query number true "Longitude maximale (est) de la zone"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Success 200 {object} dto.IncidentChangesDTO "Incidents modifiés et nouveau jeton"
+// @Failure 400 {object} ErrorResponse "Paramètres ou jeton invalides"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/changes [get]
+func (s *Server) GetIncidentChanges() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		box, err := decodeBoundingBox(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		changes, err := s.service.GetIncidentChanges(r.Context(), box, r.URL.Query().Get("since"))
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		include := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		for i := range changes.Created {
+			ids.AddIncident(&changes.Created[i], include)
+		}
+		for i := range changes.Updated {
+			ids.AddIncident(&changes.Updated[i], include)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
+		changesDTO := &dto.IncidentChangesDTO{
+			Created:   make([]dto.IncidentDTO, len(changes.Created)),
+			Updated:   make([]dto.IncidentDTO, len(changes.Updated)),
+			Expired:   make([]int64, len(changes.Expired)),
+			SyncToken: changes.Next.Encode(),
+			HasMore:   changes.HasMore,
+		}
+		for i, incident := range changes.Created {
+			changesDTO.Created[i] = *dto.IncidentToDTO(&incident, include, users)
+		}
+		for i, incident := range changes.Updated {
+			changesDTO.Updated[i] = *dto.IncidentToDTO(&incident, include, users)
+		}
+		for i, incident := range changes.Expired {
+			changesDTO.Expired[i] = incident.ID
+		}
+
+		return encode(changesDTO, http.StatusOK, w)
+	})
+}
+
 // GetUserHistory godoc
 // @Summary Récupérer l’historique des incidents de l’utilisateur
-// @Description Récupère tous les incidents créés par l’utilisateur authentifié.
+// @Description Récupère les incidents créés par l’utilisateur authentifié, du plus récent au plus ancien.
 // @Description L'historique ne comprend que les incidents qui ont été supprimés.
+// @Description Les résultats sont paginés : le champ next_cursor est à passer au paramètre cursor pour obtenir la page suivante, il vaut null sur la dernière page.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
 // @Tags incidents
 // @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Produce application/geo+json
 // @Param include query string false "Inclure des données additionnelles : 'interactions' pour les détails complets ou 'summary' pour les statistiques" Enums(interactions,summary)
-// @Success 200 {array} dto.IncidentDTO "Liste des anciens incidents (supprimés) de l'utilisateur"
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Param cursor query string false "Curseur de la page à récupérer"
+// @Param limit query integer false "Nombre d'incidents par page (20 par défaut, 100 maximum)"
+// @Param from query string false "Date de création minimale (RFC 3339 ou AAAA-MM-JJ)"
+// @Param to query string false "Date de création maximale (RFC 3339 ou AAAA-MM-JJ, une date seule inclut toute la journée)"
+// @Param type_id query integer false "Filtrer par type d'incident"
+// @Success 200 {object} dto.PageDTO[dto.IncidentDTO] "Page des anciens incidents (supprimés) de l'utilisateur"
+// @Failure 400 {object} ErrorResponse "Paramètres invalides"
 // @Failure 401 {object} ErrorResponse "Utilisateur non authentifié"
 // @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
 // @Router /incidents/me/history [get]
@@ -146,18 +556,154 @@ func (s *Server) GetUserHistory() http.HandlerFunc {
 			return encodeNil(http.StatusUnauthorized, w)
 		}
 
-		incidents, err := s.service.GetUserHistory(r.Context(), user)
+		query, err := decodeUserHistoryParams(r)
 		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+		if err := query.Validate(); err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		incidents, next, total, err := s.service.GetUserHistory(r.Context(), user, query)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
 			return err
 		}
 
 		interactionState := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		for i := range incidents {
+			ids.AddIncident(&incidents[i], interactionState)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
 		var incidentsDTOs = make([]dto.IncidentDTO, len(incidents))
 		for i, incident := range incidents {
-			incidentsDTOs[i] = *dto.IncidentToDTO(&incident, interactionState)
+			incidentsDTOs[i] = *dto.IncidentToDTO(&incident, interactionState, users)
 		}
 
-		return encode(incidentsDTOs, http.StatusOK, w)
+		return encodeIncidentsPage(toPageDTO(incidentsDTOs, next, total), r, w)
+	})
+}
+
+// GetUserActiveIncidents godoc
+// @Summary Récupérer les incidents en cours de l’utilisateur
+// @Description Récupère les incidents créés par l’utilisateur authentifié qui sont toujours en cours, du plus récent au plus ancien.
+// @Description Les résultats sont paginés : le champ next_cursor est à passer au paramètre cursor pour obtenir la page suivante, il vaut null sur la dernière page.
+// @Description Avec le paramètre format=geojson ou le header Accept: application/geo+json, la réponse est une FeatureCollection GeoJSON dont les propriétés reprennent les champs de chaque incident, résumé des interactions inclus.
+// @Tags incidents
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Produce application/geo+json
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour les détails complets ou 'summary' pour les statistiques" Enums(interactions,summary)
+// @Param format query string false "Format de la réponse, équivalent au header Accept: application/geo+json" Enums(geojson)
+// @Param cursor query string false "Curseur de la page à récupérer"
+// @Param limit query integer false "Nombre d'incidents par page (20 par défaut, 100 maximum)"
+// @Success 200 {object} dto.PageDTO[dto.IncidentDTO] "Page des incidents en cours de l'utilisateur"
+// @Failure 400 {object} ErrorResponse "Paramètres invalides"
+// @Failure 401 {object} ErrorResponse "Utilisateur non authentifié"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/me/active [get]
+func (s *Server) GetUserActiveIncidents() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		user, ok := r.Context().Value("user").(*dto.PartialUserDTO)
+		if !ok {
+			return encodeNil(http.StatusUnauthorized, w)
+		}
+
+		query, err := decodePageParams(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+		if err := query.Validate(); err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		incidents, next, total, err := s.service.GetUserActiveIncidents(r.Context(), user, query)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		interactionState := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		for i := range incidents {
+			ids.AddIncident(&incidents[i], interactionState)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
+		var incidentsDTOs = make([]dto.IncidentDTO, len(incidents))
+		for i, incident := range incidents {
+			incidentsDTOs[i] = *dto.IncidentToDTO(&incident, interactionState, users)
+		}
+
+		return encodeIncidentsPage(toPageDTO(incidentsDTOs, next, total), r, w)
+	})
+}
+
+// GetUserInteractions godoc
+// @Summary Récupérer les interactions de l’utilisateur
+// @Description Récupère les interactions (votes) de l’utilisateur authentifié, de la plus récente à la plus ancienne, avec l'incident associé.
+// @Description Par défaut, l'incident associé inclut un résumé de ses interactions.
+// @Description Les résultats sont paginés : le champ next_cursor est à passer au paramètre cursor pour obtenir la page suivante, il vaut null sur la dernière page.
+// @Tags interactions
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param include query string false "Données de l'incident associé : 'interactions' pour les détails complets ou 'summary' pour les statistiques" Enums(interactions,summary)
+// @Param cursor query string false "Curseur de la page à récupérer"
+// @Param limit query integer false "Nombre d'interactions par page (20 par défaut, 100 maximum)"
+// @Success 200 {object} dto.PageDTO[dto.InteractionDTO] "Page des interactions de l'utilisateur"
+// @Failure 400 {object} ErrorResponse "Paramètres invalides"
+// @Failure 401 {object} ErrorResponse "Utilisateur non authentifié"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/me/interactions [get]
+func (s *Server) GetUserInteractions() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		user, ok := r.Context().Value("user").(*dto.PartialUserDTO)
+		if !ok {
+			return encodeNil(http.StatusUnauthorized, w)
+		}
+
+		query, err := decodePageParams(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+		if err := query.Validate(); err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		interactions, next, total, err := s.service.GetUserInteractions(r.Context(), user, query)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		// L'incident associé est toujours inclus
+		include := decodeIncludeParam(r)
+		if include == dto.Ignore {
+			include = dto.IncludeAsSummary
+		}
+
+		ids := dto.UserIDs{}
+		for i := range interactions {
+			ids.AddInteraction(&interactions[i], include)
+		}
+		users := s.users.Resolve(r.Context(), ids)
+
+		var interactionsDTOs = make([]dto.InteractionDTO, len(interactions))
+		for i, interaction := range interactions {
+			interactionsDTOs[i] = *dto.InteractionToDTO(interaction, include, users)
+		}
+
+		return encode(toPageDTO(interactionsDTOs, next, total), http.StatusOK, w)
 	})
 }
 
@@ -167,7 +713,10 @@ func (s *Server) GetUserHistory() http.HandlerFunc {
 // @Tags incidents types
 // @Accept json
 // @Produce json
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
 // @Success 200 {array} dto.TypeDTO "Liste des types d'incidents"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
 // @Failure 500 {object} services.ErrorWithCode "Erreur interne du serveur"
 // @Router /incidents/types [get]
 func (s *Server) GetIncidentsTypes() http.HandlerFunc {
@@ -177,6 +726,14 @@ func (s *Server) GetIncidentsTypes() http.HandlerFunc {
 			return err
 		}
 
+		rows := make([]*models.Type, len(types))
+		for i := range types {
+			rows[i] = &types[i]
+		}
+		if notModified(w, r, typesETag(rows...), time.Time{}, cacheTypes) {
+			return nil
+		}
+
 		typesDTOs := make([]dto.TypeDTO, len(types))
 		for i, t := range types {
 			typesDTOs[i] = *dto.TypeToDTO(&t)
@@ -193,7 +750,10 @@ func (s *Server) GetIncidentsTypes() http.HandlerFunc {
 // @Accept json
 // @Produce json
 // @Param id path int64 true "ID du type d'incident"
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
 // @Success 200 {object} dto.TypeDTO "Type d'incident trouvé avec succès"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
 // @Failure 400 {object} services.ErrorWithCode "ID du type d'incident invalide"
 // @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
 // @Failure 500 {object} services.ErrorWithCode "Erreur interne du serveur"
@@ -213,6 +773,10 @@ func (s *Server) GetIncidentTypeById() http.HandlerFunc {
 			return err
 		}
 
+		if notModified(w, r, typesETag(t), time.Time{}, cacheTypes) {
+			return nil
+		}
+
 		typeDTO := dto.TypeToDTO(t)
 		return encode(typeDTO, http.StatusOK, w)
 	})
@@ -221,16 +785,20 @@ func (s *Server) GetIncidentTypeById() http.HandlerFunc {
 // UserInteractWithIncident godoc
 // @Summary Crée une interaction avec un incident
 // @Description Permet à un utilisateur d'interagir avec un incident en fonction de son ID et de son statut d'interaction.
+// @Description Des photos peuvent être jointes en multipart/form-data, comme lors de la création d'un incident.
+// @Description severity_change (worse ou better) fait évoluer d'un niveau la gravité d'un incident toujours présent.
 // @Tags interactions
 // @Security BearerAuth
-// @Accept json
+// @Accept json,mpfd
 // @Produce json
-// @Param body body validations.CreateInteractionValidator true "Informations de l'interaction"
+// @Param body body validations.CreateInteractionValidator true "Informations de l'interaction, dans le champ data en multipart/form-data"
+// @Param photos formData file false "Photo jointe à l'interaction (PHOTOS_MAX_COUNT au plus)"
 // @Success 200 {object} dto.InteractionDTO "Interaction créée avec succès"
 // @Failure 400 {object} services.ErrorWithCode "Paramètres invalides"
 // @Failure 403 {object} services.ErrorWithCode "Interaction avec son propre incident"
 // @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
 // @Failure 409 {object} services.ErrorWithCode "Incident verrouillé"
+// @Failure 413 {object} services.ErrorWithCode "Photo trop volumineuse"
 // @Failure 429 {object} services.ErrorWithCode "Trop d'interactions avec cet incident"
 // @Failure 500 {object} services.ErrorWithCode "Erreur interne du serveur"
 // @Router /incidents/interactions [post]
@@ -241,12 +809,12 @@ func (s *Server) UserInteractWithIncident() http.HandlerFunc {
 			return encodeNil(http.StatusUnauthorized, w)
 		}
 
-		body, err := handler.Decode[validations.CreateInteractionValidator](r)
+		body, uploads, err := decodeWithPhotos[validations.CreateInteractionValidator](w, r, s.Config)
 		if err != nil {
-			return buildValidationErrors(err, w)
+			return buildDecodeErrors(err, w)
 		}
 
-		interaction, err := s.service.CreateInteraction(r.Context(), user, &body)
+		interaction, err := s.service.CreateInteraction(r.Context(), user, &body, uploads)
 		if err != nil {
 			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
 				return encode(ewc, ewc.Code, w)
@@ -255,7 +823,9 @@ func (s *Server) UserInteractWithIncident() http.HandlerFunc {
 		}
 
 		includeParam := decodeIncludeParam(r)
-		interactionDTO := dto.InteractionToDTO(*interaction, includeParam)
+		ids := dto.UserIDs{}
+		ids.AddInteraction(interaction, includeParam)
+		interactionDTO := dto.InteractionToDTO(*interaction, includeParam, s.users.Resolve(r.Context(), ids))
 		return encode(interactionDTO, http.StatusOK, w)
 	})
 }
@@ -285,12 +855,18 @@ func decodeParamAs[T any](r *http.Request, param string) (T, error) {
 	case float64:
 		result, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
 	case *int64:
-		parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		var parsed int64
+		parsed, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
 		if err == nil {
 			result = &parsed
 		}
 	case int64:
 		result, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	case time.Time:
+		result, err = time.Parse(time.RFC3339, strings.TrimSpace(value))
+		if err != nil {
+			result, err = time.Parse(time.DateOnly, strings.TrimSpace(value))
+		}
 	default:
 		err = fmt.Errorf("unsupported type %s", reflect.TypeOf(zero).String())
 	}
@@ -302,6 +878,85 @@ func decodeParamAs[T any](r *http.Request, param string) (T, error) {
 	return result.(T), nil
 }
 
+// decodeOptionalParamAs retourne nil si le paramètre n'est pas fourni
+func decodeOptionalParamAs[T any](r *http.Request, param string) (*T, error) {
+	if r.URL.Query().Get(param) == "" {
+		return nil, nil
+	}
+
+	value, err := decodeParamAs[T](r, param)
+	if err != nil {
+		return nil, err
+	}
+	return &value, nil
+}
+
+func decodePageParams(r *http.Request) (*validations.PageValidator, error) {
+	limit, err := decodeOptionalParamAs[int64](r, "limit")
+	if err != nil {
+		return nil, err
+	}
+
+	page := &validations.PageValidator{
+		Cursor: r.URL.Query().Get("cursor"),
+	}
+	if limit != nil {
+		page.Limit = int(*limit)
+	}
+
+	return page, nil
+}
+
+func decodeUserHistoryParams(r *http.Request) (*validations.UserHistoryValidator, error) {
+	page, err := decodePageParams(r)
+	if err != nil {
+		return nil, err
+	}
+
+	query := &validations.UserHistoryValidator{PageValidator: *page}
+	if query.From, err = decodeOptionalParamAs[time.Time](r, "from"); err != nil {
+		return nil, err
+	}
+	if query.To, err = decodeOptionalParamAs[time.Time](r, "to"); err != nil {
+		return nil, err
+	}
+	// Une date sans heure inclut toute la journée : la borne devient le lendemain, exclu
+	if query.To != nil && isDateOnly(r.URL.Query().Get("to")) {
+		before := query.To.Add(24 * time.Hour)
+		query.To, query.Before = nil, &before
+	}
+	if query.TypeId, err = decodeOptionalParamAs[int64](r, "type_id"); err != nil {
+		return nil, err
+	}
+
+	return query, nil
+}
+
+func isDateOnly(value string) bool {
+	_, err := time.Parse(time.DateOnly, strings.TrimSpace(value))
+	return err == nil
+}
+
+func decodeBoundingBox(r *http.Request) (*helpers.BoundingBox, error) {
+	var box helpers.BoundingBox
+	var err error
+
+	if box.MinLat, err = decodeParamAs[float64](r, "min_lat"); err != nil {
+		return nil, err
+	}
+	if box.MinLon, err = decodeParamAs[float64](r, "min_lon"); err != nil {
+		return nil, err
+	}
+	if box.MaxLat, err = decodeParamAs[float64](r, "max_lat"); err != nil {
+		return nil, err
+	}
+	if box.MaxLon, err = decodeParamAs[float64](r, "max_lon"); err != nil {
+		return nil, err
+	}
+
+	return &box, nil
+}
+
 func decodeIncludeParam(r *http.Request) dto.InteractionsResultState {
 	include := r.URL.Query().Get("include")
 
@@ -312,12 +967,65 @@ func decodeIncludeParam(r *http.Request) dto.InteractionsResultState {
 	case "summary":
 		interactionState = dto.IncludeAsSummary
 	default:
-		interactionState = dto.Ignore
+		// Les propriétés GeoJSON incluent toujours le résumé des interactions
+		if wantsGeoJSON(r) {
+			interactionState = dto.IncludeAsSummary
+		} else {
+			interactionState = dto.Ignore
+		}
 	}
 
 	return interactionState
 }
 
+// wantsGeoJSON indique si le client demande une réponse GeoJSON,
+// via le paramètre format=geojson ou le header Accept: application/geo+json
+func wantsGeoJSON(r *http.Request) bool {
+	if r.URL.Query().Get("format") == "geojson" {
+		return true
+	}
+	return strings.Contains(r.Header.Get("Accept"), geoJSONContentType)
+}
+
+const geoJSONContentType = "application/geo+json"
+
+func encodeGeoJSON(body any, status int, w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", geoJSONContentType)
+	w.Header().Set("Vary", "Accept")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	return nil
+}
+
+func toPageDTO[T any](items []T, next *helpers.Cursor, total int) *dto.PageDTO[T] {
+	page := &dto.PageDTO[T]{
+		Items: items,
+		Total: total,
+	}
+	if next != nil {
+		page.NextCursor = toPtr(next.Encode())
+	}
+	return page
+}
+
+func encodeIncidentsPage(page *dto.PageDTO[dto.IncidentDTO], r *http.Request, w http.ResponseWriter) error {
+	if wantsGeoJSON(r) {
+		collection := dto.ToFeatureCollection(page.Items)
+		collection.NextCursor = page.NextCursor
+		collection.Total = &page.Total
+		return encodeGeoJSON(collection, http.StatusOK, w)
+	}
+
+	return encode(page, http.StatusOK, w)
+}
+
+func toPtr[T any](t T) *T {
+	return &t
+}
+
 func encodeNil(status int, w http.ResponseWriter) error {
 	return encode(nil, status, w)
 }
@@ -3,19 +3,20 @@ package api
 import (
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/matheodrd/httphelper/handler"
-	"io"
+	"log/slog"
 	"net/http"
-	"supmap-users/internal/config"
 	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/auth"
 )
 
 func WithCORS(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Last-Event-ID, If-None-Match, If-Modified-Since")
+		w.Header().Set("Access-Control-Expose-Headers", "ETag, Last-Modified")
 
 		if r.Method == http.MethodOptions { // Ignore preflight requests because OPTIONS handler is not implemented
 			w.WriteHeader(http.StatusOK)
@@ -48,58 +49,44 @@ func (s *Server) AuthMiddleware() func(http.Handler) http.Handler {
 				return
 			}
 
-			// Requête vers le service users
-			req, err := http.NewRequestWithContext(r.Context(), "GET", fmt.Sprintf("%s/internal/users/check-auth", config.UsersBaseUrl), nil)
+			user, err := s.authenticate(r.Context(), authHeader)
 			if err != nil {
-				s.log.Error("failed to create auth check request", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
+				writeAuthError(w, err, s.log)
 				return
 			}
-			req.Header.Set("Authorization", authHeader)
 
-			client := &http.Client{}
-			res, err := client.Do(req)
-			if err != nil {
-				s.log.Error("failed to check auth", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			defer func(Body io.ReadCloser) {
-				err := Body.Close()
-				if err != nil {
-					fmt.Printf("failed to close response body: %v", err)
-				}
-			}(res.Body)
-
-			if res.StatusCode != http.StatusOK {
-				w.WriteHeader(res.StatusCode)
-				switch res.StatusCode {
-				case http.StatusUnauthorized:
-					err = json.NewEncoder(w).Encode(invalidToken)
-				case http.StatusForbidden:
-					err = json.NewEncoder(w).Encode(sessionExpired)
-				default:
-					err = json.NewEncoder(w).Encode(invalidUser)
-				}
+			ctx := context.WithValue(r.Context(), "user", user)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
 
-				if err != nil {
-					w.WriteHeader(http.StatusInternalServerError)
-				}
+// authenticate retourne l'utilisateur associé au header Authorization
+func (s *Server) authenticate(ctx context.Context, authHeader string) (*dto.PartialUserDTO, error) {
+	return s.auth.Authenticate(ctx, authHeader)
+}
 
-				return
-			}
+func writeAuthError(w http.ResponseWriter, err error, log *slog.Logger) {
+	var rejection *auth.Rejection
+	if !errors.As(err, &rejection) {
+		log.Error("failed to authenticate user", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
 
-			// Désérialisation de l'utilisateur
-			var user dto.PartialUserDTO
-			if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
-				s.log.Error("failed to decode user from auth response", "error", err)
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
+	var body *AuthError
+	switch rejection.Status {
+	case http.StatusUnauthorized:
+		body = invalidToken
+	case http.StatusForbidden:
+		body = sessionExpired
+	default:
+		body = invalidUser
+	}
 
-			ctx := context.WithValue(r.Context(), "user", &user)
-			next.ServeHTTP(w, r.WithContext(ctx))
-		})
+	w.WriteHeader(rejection.Status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
 
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"github.com/matheodrd/httphelper/handler"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/models"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services"
+)
+
+// ExpireIncident godoc
+// @Summary Forcer l'expiration d'un incident
+// @Description Permet à un administrateur de supprimer un incident en cours. Un message 'deleted' est publié dans Redis.
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Success 200 {object} dto.IncidentDTO "Incident expiré"
+// @Failure 400 {object} ErrorResponse "ID de l'incident invalide"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
+// @Failure 409 {object} services.ErrorWithCode "Incident déjà expiré"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/moderation/{id}/expire [patch]
+func (s *Server) ExpireIncident() http.HandlerFunc {
+	return s.moderateIncident(s.service.ExpireIncident)
+}
+
+// RestoreIncident godoc
+// @Summary Rétablir un incident expiré
+// @Description Permet à un administrateur de rétablir un incident expiré à tort. Un message 'restored' est publié dans Redis.
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Success 200 {object} dto.IncidentDTO "Incident rétabli"
+// @Failure 400 {object} ErrorResponse "ID de l'incident invalide"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
+// @Failure 409 {object} services.ErrorWithCode "Incident non expiré ou ayant dépassé sa durée de vie maximale"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/moderation/{id}/restore [patch]
+func (s *Server) RestoreIncident() http.HandlerFunc {
+	return s.moderateIncident(s.service.RestoreIncident)
+}
+
+// UpdateIncidentType godoc
+// @Summary Réaffecter le type d'un incident
+// @Description Permet à un administrateur de modifier le type d'un incident. Un message 'updated' est publié dans Redis.
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'incident"
+// @Param include query string false "Inclure des données additionnelles : 'interactions' pour le détail complet ou 'summary' pour un résumé" Enums(interactions,summary)
+// @Param body body validations.UpdateIncidentTypeValidator true "Nouveau type de l'incident"
+// @Success 200 {object} dto.IncidentDTO "Incident mis à jour"
+// @Failure 400 {object} services.ErrorWithCode "Type d'incident inexistant ou données invalides"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/moderation/{id}/type [patch]
+func (s *Server) UpdateIncidentType() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident id"}, http.StatusBadRequest, w)
+		}
+
+		body, err := handler.Decode[validations.UpdateIncidentTypeValidator](r)
+		if err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		incident, err := s.service.UpdateIncidentType(r.Context(), id, &body)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		include := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		ids.AddIncident(incident, include)
+		incidentDTO := dto.IncidentToDTO(incident, include, s.users.Resolve(r.Context(), ids))
+		return encode(incidentDTO, http.StatusOK, w)
+	})
+}
+
+// DeleteInteraction godoc
+// @Summary Supprimer une interaction
+// @Description Permet à un administrateur de supprimer une interaction abusive. Un message 'updated' est publié dans Redis pour l'incident associé.
+// @Tags moderation
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID de l'interaction"
+// @Success 204 {object} nil "Interaction supprimée"
+// @Failure 400 {object} ErrorResponse "ID de l'interaction invalide"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Interaction non trouvée"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/interactions/{id} [delete]
+func (s *Server) DeleteInteraction() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid interaction id"}, http.StatusBadRequest, w)
+		}
+
+		if err := s.service.DeleteInteraction(r.Context(), id); err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		return encodeNil(http.StatusNoContent, w)
+	})
+}
+
+func (s *Server) moderateIncident(moderate func(ctx context.Context, id int64) (*models.Incident, error)) http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident id"}, http.StatusBadRequest, w)
+		}
+
+		incident, err := moderate(r.Context(), id)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		include := decodeIncludeParam(r)
+		ids := dto.UserIDs{}
+		ids.AddIncident(incident, include)
+		incidentDTO := dto.IncidentToDTO(incident, include, s.users.Resolve(r.Context(), ids))
+		return encode(incidentDTO, http.StatusOK, w)
+	})
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/matheodrd/httphelper/handler"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"supmap-users/internal/config"
+	"supmap-users/internal/services"
+	"supmap-users/internal/services/photos"
+	"time"
+)
+
+// Champs des requêtes multipart/form-data : les données JSON de la requête et les photos jointes
+const (
+	dataField   = "data"
+	photosField = "photos"
+)
+
+// maxDataSize est la taille maximale du champ data d'une requête multipart
+const maxDataSize = 1 << 20
+
+// formError est retournée lorsque le corps multipart ne peut pas être lu
+type formError struct {
+	status  int
+	message string
+}
+
+func (e *formError) Error() string {
+	return e.message
+}
+
+// GetIncidentPhoto godoc
+// @Summary Récupérer une photo
+// @Description Retourne une photo jointe à un incident ou sa miniature, au format JPEG.
+// @Description Les URLs des photos sont indiquées dans le champ photos des incidents, le contenu d'une clé ne change jamais.
+// @Tags incidents
+// @Produce image/jpeg
+// @Param key path string true "Clé de la photo ou de la miniature"
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
+// @Success 200 {file} binary "Photo au format JPEG"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
+// @Failure 404 {object} services.ErrorWithCode "Photo non trouvée"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/photos/{key} [get]
+func (s *Server) GetIncidentPhoto() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		content, err := s.service.GetPhoto(r.Context(), r.PathValue("key"))
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		if notModified(w, r, contentETag(content), time.Time{}, cachePhotos) {
+			return nil
+		}
+
+		w.Header().Set("Content-Type", photos.ContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(content)
+		return err
+	})
+}
+
+// decodeWithPhotos lit le corps d'une requête JSON, ou d'une requête multipart/form-data dont le champ data
+// contient le JSON et les champs photos les fichiers joints. Les fichiers sont lus en mémoire dans la limite
+// de PHOTOS_MAX_COUNT photos de PHOTOS_MAX_SIZE octets.
+func decodeWithPhotos[T handler.Validator](w http.ResponseWriter, r *http.Request, conf *config.Config) (T, [][]byte, error) {
+	var body T
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err := handler.Decode[T](r)
+		return body, nil, err
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(conf.PhotosMaxCount)*conf.PhotosMaxSize+maxDataSize)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return body, nil, &formError{status: http.StatusBadRequest, message: "invalid multipart body"}
+	}
+
+	var uploads [][]byte
+	hasData := false
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return body, nil, readError(err)
+		}
+
+		switch part.FormName() {
+		case dataField:
+			if err := json.NewDecoder(io.LimitReader(part, maxDataSize)).Decode(&body); err != nil {
+				return body, nil, &formError{status: http.StatusBadRequest, message: fmt.Sprintf("invalid %s field", dataField)}
+			}
+			hasData = true
+		case photosField:
+			if len(uploads) == conf.PhotosMaxCount {
+				return body, nil, &formError{
+					status:  http.StatusBadRequest,
+					message: fmt.Sprintf("too many photos, at most %d are allowed", conf.PhotosMaxCount),
+				}
+			}
+			content, err := io.ReadAll(io.LimitReader(part, conf.PhotosMaxSize+1))
+			if err != nil {
+				return body, nil, readError(err)
+			}
+			if int64(len(content)) > conf.PhotosMaxSize {
+				return body, nil, &formError{
+					status:  http.StatusRequestEntityTooLarge,
+					message: fmt.Sprintf("photo %d exceeds the maximum size of %d bytes", len(uploads)+1, conf.PhotosMaxSize),
+				}
+			}
+			uploads = append(uploads, content)
+		}
+	}
+
+	if !hasData {
+		return body, nil, &formError{status: http.StatusBadRequest, message: fmt.Sprintf("missing %s field", dataField)}
+	}
+	if err := body.Validate(); err != nil {
+		return body, nil, err
+	}
+	return body, uploads, nil
+}
+
+func readError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return &formError{status: http.StatusRequestEntityTooLarge, message: "request body too large"}
+	}
+	return &formError{status: http.StatusBadRequest, message: "invalid multipart body"}
+}
+
+// buildDecodeErrors répond aux erreurs de lecture du corps multipart puis aux erreurs de validation
+func buildDecodeErrors(err error, w http.ResponseWriter) error {
+	var fe *formError
+	if errors.As(err, &fe) {
+		return encode(&ErrorResponse{Error: fe.message}, fe.status, w)
+	}
+	return buildValidationErrors(err, w)
+}
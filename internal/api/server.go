@@ -7,19 +7,31 @@ import (
 	_ "supmap-users/docs"
 	"supmap-users/internal/config"
 	"supmap-users/internal/services"
+	"supmap-users/internal/services/auth"
+	"supmap-users/internal/services/stream"
+	"supmap-users/internal/services/tiles"
+	"supmap-users/internal/services/users"
 )
 
 type Server struct {
 	Config  *config.Config
 	log     *slog.Logger
 	service *services.Service
+	hub     *stream.Hub
+	users   *users.Resolver
+	auth    *auth.Authenticator
+	tiles   *tiles.Cache
 }
 
-func NewServer(config *config.Config, log *slog.Logger, service *services.Service) *Server {
+func NewServer(config *config.Config, log *slog.Logger, service *services.Service, hub *stream.Hub, users *users.Resolver, auth *auth.Authenticator, tiles *tiles.Cache) *Server {
 	return &Server{
 		Config:  config,
 		log:     log,
 		service: service,
+		hub:     hub,
+		users:   users,
+		auth:    auth,
+		tiles:   tiles,
 	}
 }
 
@@ -33,16 +45,39 @@ func (s *Server) Start() error {
 	mux.Handle("/docs/", httpSwagger.WrapHandler)
 
 	mux.Handle("GET /incidents", s.GetAllInRadius())
+	mux.Handle("GET /incidents/bbox", s.GetAllInBoundingBox())
+	mux.Handle("GET /incidents/geohash/{prefix}", s.GetAllInGeohash())
+	mux.Handle("GET /incidents/changes", s.GetIncidentChanges())
+	mux.Handle("GET /incidents/clusters", s.GetIncidentClusters())
+	mux.Handle("GET /incidents/stream", s.StreamIncidents())
+	mux.Handle("GET /incidents/tiles/{z}/{x}/{y}", s.GetIncidentsTile())
+	mux.Handle("GET /incidents/photos/{key}", s.GetIncidentPhoto())
+	mux.Handle("GET /incidents/ws", s.IncidentsWebSocket())
 	mux.Handle("GET /incidents/me/history", s.AuthMiddleware()(s.GetUserHistory()))
+	mux.Handle("GET /incidents/me/active", s.AuthMiddleware()(s.GetUserActiveIncidents()))
+	mux.Handle("GET /incidents/me/interactions", s.AuthMiddleware()(s.GetUserInteractions()))
 	mux.Handle("GET /incidents/types", s.GetIncidentsTypes())
 	mux.Handle("GET /incidents/types/{id}", s.GetIncidentTypeById())
+	mux.Handle("POST /incidents/types", s.AuthMiddleware()(s.AdminMiddleware()(s.CreateIncidentType())))
+	mux.Handle("PATCH /incidents/types/{id}", s.AuthMiddleware()(s.AdminMiddleware()(s.UpdateIncidentTypeSettings())))
+	mux.Handle("DELETE /incidents/types/{id}", s.AuthMiddleware()(s.AdminMiddleware()(s.DisableIncidentType())))
+	mux.Handle("GET /incidents/{id}", s.GetIncidentById())
 	mux.Handle("POST /incidents", s.AuthMiddleware()(s.CreateIncident()))
+	mux.Handle("PATCH /incidents/{id}", s.AuthMiddleware()(s.UpdateIncidentDescription()))
 
 	mux.Handle("POST /incidents/interactions", s.AuthMiddleware()(s.UserInteractWithIncident()))
 
+	// Moderation routes, restricted to administrators
+	mux.Handle("PATCH /incidents/moderation/{id}/expire", s.AuthMiddleware()(s.AdminMiddleware()(s.ExpireIncident())))
+	mux.Handle("PATCH /incidents/moderation/{id}/restore", s.AuthMiddleware()(s.AdminMiddleware()(s.RestoreIncident())))
+	mux.Handle("PATCH /incidents/moderation/{id}/type", s.AuthMiddleware()(s.AdminMiddleware()(s.UpdateIncidentType())))
+	mux.Handle("DELETE /incidents/interactions/{id}", s.AuthMiddleware()(s.AdminMiddleware()(s.DeleteInteraction())))
+
 	// These routes are not exposed outside the LAN
 	//  server network and doesn't require securities
 	mux.Handle("GET /internal/incidents", s.GetAllInRadius())
+	mux.Handle("GET /internal/incidents/{id}", s.GetIncidentById())
+	mux.Handle("POST /internal/incidents/route", s.GetAllAlongRoute())
 
 	server := &http.Server{
 		Addr:    ":" + s.Config.PORT,
@@ -0,0 +1,156 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/matheodrd/httphelper/handler"
+	"io"
+	"net/http"
+	"strconv"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/services/stream"
+	"time"
+)
+
+// StreamIncidents godoc
+// @Summary Flux temps réel des incidents d'une zone
+// @Description Ouvre un flux Server-Sent Events qui transmet les événements des incidents situés dans la zone demandée.
+// @Description La zone est une bounding box (min_lat, min_lon, max_lat, max_lon) ou un cercle (lat, lon, radius).
+// @Description Le nom de chaque événement correspond à l'action Redis (create, certified, deleted, restored, updated) et ses données à l'incident concerné.
+// @Description Un commentaire heartbeat est envoyé régulièrement pour maintenir la connexion ouverte.
+// @Description À la reconnexion, le header Last-Event-ID (ou le paramètre last_event_id) permet de recevoir les événements manqués.
+// @Description Si certains ne sont plus disponibles, un événement reset est envoyé et le client doit recharger les incidents de la zone.
+// @Tags incidents
+// @Produce text/event-stream
+// @Param min_lat query number false "Latitude minimale de la zone"
+// @Param min_lon query number false "Longitude minimale (ouest) de la zone"
+// @Param max_lat query number false "Latitude maximale de la zone"
+// @Param max_lon query number false "Longitude maximale (est) de la zone"
+// @Param lat query number false "Latitude du centre de la zone"
+// @Param lon query number false "Longitude du centre de la zone"
+// @Param radius query integer false "Rayon de la zone en mètres"
+// @Param last_event_id query integer false "Identifiant du dernier événement reçu, équivalent au header Last-Event-ID"
+// @Param Last-Event-ID header integer false "Identifiant du dernier événement reçu"
+// @Success 200 {string} string "Flux d'événements, les données de chaque événement sont un dto.IncidentRedis"
+// @Failure 400 {object} ErrorResponse "Zone invalide ou manquante"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/stream [get]
+func (s *Server) StreamIncidents() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		area, err := decodeArea(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		lastEventID, err := decodeLastEventID(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		controller := http.NewResponseController(w)
+
+		sub, missed, complete := s.hub.Subscribe(area, lastEventID)
+		defer s.hub.Unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no") // Désactive la mise en tampon des reverse proxies
+		w.WriteHeader(http.StatusOK)
+
+		if !complete {
+			if _, err := fmt.Fprint(w, "event: reset\ndata: {}\n\n"); err != nil {
+				return nil
+			}
+		}
+		for _, event := range missed {
+			if err := writeStreamEvent(w, &event); err != nil {
+				return nil
+			}
+		}
+		if err := controller.Flush(); err != nil {
+			return err
+		}
+
+		heartbeat := time.NewTicker(s.Config.StreamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return nil
+			case event, ok := <-sub.Events:
+				if !ok {
+					// Déconnecté par le hub, le client se reconnectera avec Last-Event-ID
+					return nil
+				}
+				if err := writeStreamEvent(w, &event); err != nil {
+					return nil
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+					return nil
+				}
+			}
+
+			if err := controller.Flush(); err != nil {
+				return nil
+			}
+		}
+	})
+}
+
+func writeStreamEvent(w io.Writer, event *stream.Event) error {
+	data, err := json.Marshal(event.Message.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Message.Action, data)
+	return err
+}
+
+// decodeArea lit une bounding box si min_lat est fourni, un cercle sinon
+func decodeArea(r *http.Request) (helpers.Area, error) {
+	if r.URL.Query().Has("min_lat") {
+		box, err := decodeBoundingBox(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := box.Validate(); err != nil {
+			return nil, err
+		}
+		return box, nil
+	}
+
+	var circle helpers.Circle
+	var err error
+	if circle.Lat, err = decodeParamAs[float64](r, "lat"); err != nil {
+		return nil, err
+	}
+	if circle.Lon, err = decodeParamAs[float64](r, "lon"); err != nil {
+		return nil, err
+	}
+	if circle.Radius, err = decodeParamAs[float64](r, "radius"); err != nil {
+		return nil, err
+	}
+	if err := circle.Validate(); err != nil {
+		return nil, err
+	}
+	return &circle, nil
+}
+
+func decodeLastEventID(r *http.Request) (*uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value for Last-Event-ID: %w", err)
+	}
+	return &id, nil
+}
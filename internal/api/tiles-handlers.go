@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"github.com/matheodrd/httphelper/handler"
+	"github.com/paulmach/orb/maptile"
+	"net/http"
+	"strconv"
+	"strings"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services"
+	"time"
+)
+
+const vectorTileContentType = "application/vnd.mapbox-vector-tile"
+
+// GetIncidentsTile godoc
+// @Summary Récupérer les incidents d'une tuile vectorielle
+// @Description Encode les incidents en cours de la tuile {z}/{x}/{y} au format Mapbox Vector Tile, dans la couche incidents.
+// @Description Chaque incident est un point dont les propriétés sont id, type_id, type_name, created_at (timestamp Unix), age (en secondes), certified, is_still_present, no_still_present et total.
+// @Description Les tuiles sont mises en cache et invalidées lors de la création, la certification, la suppression ou la modification d'un incident qu'elles contiennent.
+// @Description Au plus TILES_MAX_FEATURES incidents sont encodés par tuile, les plus récents en priorité.
+// @Tags incidents
+// @Produce application/vnd.mapbox-vector-tile
+// @Param z path integer true "Niveau de zoom (0 à 22)"
+// @Param x path integer true "Colonne de la tuile"
+// @Param y path string true "Ligne de la tuile suivie de l'extension .mvt"
+// @Param If-None-Match header string false "ETag d'une réponse précédente"
+// @Success 200 {file} binary "Tuile vectorielle"
+// @Header 200 {string} ETag "Version de la réponse"
+// @Success 304 {object} nil "Réponse inchangée depuis la version détenue par le client"
+// @Failure 400 {object} ErrorResponse "Coordonnées de la tuile invalides"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/tiles/{z}/{x}/{y}.mvt [get]
+func (s *Server) GetIncidentsTile() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		tile, err := decodeTileParams(r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: err.Error()}, http.StatusBadRequest, w)
+		}
+
+		content, err := s.tiles.Load(tile, func() ([]byte, error) {
+			// L'encodage est partagé avec les requêtes simultanées sur la même tuile,
+			// il ne doit pas être interrompu si ce client se déconnecte
+			incidents, err := s.service.GetIncidentsTile(context.WithoutCancel(r.Context()), tile)
+			if err != nil {
+				return nil, err
+			}
+			return dto.IncidentsToVectorTile(incidents, tile)
+		})
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		if notModified(w, r, contentETag(content), time.Time{}, cacheIncidents) {
+			return nil
+		}
+
+		w.Header().Set("Content-Type", vectorTileContentType)
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.WriteHeader(http.StatusOK)
+		_, err = w.Write(content)
+		return err
+	})
+}
+
+// decodeTileParams lit les coordonnées de la tuile dans le chemin, y étant suivi de l'extension .mvt
+func decodeTileParams(r *http.Request) (maptile.Tile, error) {
+	y, found := strings.CutSuffix(r.PathValue("y"), ".mvt")
+	if !found {
+		return maptile.Tile{}, errors.New("tile must be requested with the .mvt extension")
+	}
+
+	coordinates := make([]uint32, 3)
+	for i, value := range []string{r.PathValue("z"), r.PathValue("x"), y} {
+		converted, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return maptile.Tile{}, errors.New("invalid tile coordinates")
+		}
+		coordinates[i] = uint32(converted)
+	}
+
+	return maptile.New(coordinates[1], coordinates[2], maptile.Zoom(coordinates[0])), nil
+}
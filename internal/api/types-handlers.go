@@ -0,0 +1,114 @@
+package api
+
+import (
+	"github.com/matheodrd/httphelper/handler"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services"
+)
+
+// CreateIncidentType godoc
+// @Summary Créer un type d'incident
+// @Description Permet à un administrateur de créer un nouveau type d'incident. Un message 'create' est publié dans le channel Redis des types.
+// @Description Le schéma JSON optionnel attributes_schema décrit les attributs des incidents de ce type, sa racine doit être de type object.
+// @Tags incidents types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param body body validations.CreateTypeValidator true "Données du type d'incident"
+// @Success 201 {object} dto.TypeDTO "Type d'incident créé"
+// @Failure 400 {object} validations.ValidationError "Données invalides"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/types [post]
+func (s *Server) CreateIncidentType() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		body, err := handler.Decode[validations.CreateTypeValidator](r)
+		if err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		t, err := s.service.CreateIncidentType(r.Context(), &body)
+		if err != nil {
+			return err
+		}
+
+		return encode(dto.TypeToDTO(t), http.StatusCreated, w)
+	})
+}
+
+// UpdateIncidentTypeSettings godoc
+// @Summary Modifier un type d'incident
+// @Description Permet à un administrateur de modifier les champs fournis d'un type d'incident, notamment ses seuils d'auto-modération.
+// @Description Les nouveaux seuils s'appliquent immédiatement aux incidents en cours. Le champ disabled permet de désactiver ou réactiver le type.
+// @Description Le champ attributes_schema remplace le schéma des attributs, null le retire. Les attributs des incidents existants ne sont pas revalidés.
+// @Description Un message 'deleted' est publié dans le channel Redis des types lorsque le type est désactivé, 'restored' lorsqu'il est réactivé et 'updated' sinon.
+// @Tags incidents types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID du type d'incident"
+// @Param body body validations.UpdateTypeValidator true "Champs à modifier"
+// @Success 200 {object} dto.TypeDTO "Type d'incident modifié"
+// @Failure 400 {object} services.ErrorWithCode "Données invalides"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/types/{id} [patch]
+func (s *Server) UpdateIncidentTypeSettings() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident type id"}, http.StatusBadRequest, w)
+		}
+
+		body, err := handler.Decode[validations.UpdateTypeValidator](r)
+		if err != nil {
+			return buildValidationErrors(err, w)
+		}
+
+		t, err := s.service.UpdateIncidentTypeSettings(r.Context(), id, &body)
+		if err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		return encode(dto.TypeToDTO(t), http.StatusOK, w)
+	})
+}
+
+// DisableIncidentType godoc
+// @Summary Désactiver un type d'incident
+// @Description Permet à un administrateur de désactiver un type d'incident. Il ne peut plus être signalé mais les incidents existants le conservent.
+// @Description Un message 'deleted' est publié dans le channel Redis des types.
+// @Tags incidents types
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int64 true "ID du type d'incident"
+// @Success 204 {object} nil "Type d'incident désactivé"
+// @Failure 400 {object} ErrorResponse "ID du type d'incident invalide"
+// @Failure 401 {object} nil "Utilisateur non authentifié ou non administrateur"
+// @Failure 404 {object} services.ErrorWithCode "Type d'incident non trouvé"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/types/{id} [delete]
+func (s *Server) DisableIncidentType() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		id, err := decodeParamAsInt64("id", r)
+		if err != nil {
+			return encode(&ErrorResponse{Error: "invalid incident type id"}, http.StatusBadRequest, w)
+		}
+
+		if err := s.service.DisableIncidentType(r.Context(), id); err != nil {
+			if ewc := services.DecodeErrorWithCode(err); ewc != nil {
+				return encode(ewc, ewc.Code, w)
+			}
+			return err
+		}
+
+		return encodeNil(http.StatusNoContent, w)
+	})
+}
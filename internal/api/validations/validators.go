@@ -3,6 +3,8 @@ package validations
 import (
 	"github.com/go-playground/validator/v10"
 	"reflect"
+	"supmap-users/internal/helpers"
+	"time"
 )
 
 type ValidationError struct {
@@ -15,9 +17,16 @@ func (e ValidationError) Error() string {
 }
 
 type CreateIncidentValidator struct {
-	TypeId    int64    `json:"type_id" validate:"required"`
-	Latitude  *float64 `json:"lat" validate:"required,latitude"`
-	Longitude *float64 `json:"lon" validate:"required,longitude"`
+	TypeId      int64          `json:"type_id" validate:"required"`
+	Latitude    *float64       `json:"lat" validate:"required,latitude"`
+	Longitude   *float64       `json:"lon" validate:"required,longitude"`
+	Description *string        `json:"description" validate:"omitempty,max=280"`
+	Attributes  map[string]any `json:"attributes"`
+	// Cap en degrés du sens de circulation concerné (0 pour le nord, 90 pour l'est)
+	Heading        *float64 `json:"heading" validate:"omitempty,gte=0,lt=360"`
+	BothDirections bool     `json:"both_directions"`
+	// Gravité de l'incident, moderate par défaut
+	Severity *string `json:"severity" validate:"omitempty,oneof=minor moderate major blocking"`
 }
 
 func (civ CreateIncidentValidator) Validate() error {
@@ -76,6 +85,8 @@ func validateLongitude(fl validator.FieldLevel) bool {
 type CreateInteractionValidator struct {
 	IncidentID     int64 `json:"incident_id" validate:"required"`
 	IsStillPresent *bool `json:"is_still_present" validate:"required"`
+	// Évolution de la gravité d'un incident toujours présent
+	SeverityChange *string `json:"severity_change" validate:"omitempty,oneof=worse better"`
 }
 
 func (civ CreateInteractionValidator) Validate() error {
@@ -85,3 +96,181 @@ func (civ CreateInteractionValidator) Validate() error {
 	}
 	return nil
 }
+
+type UpdateIncidentDescriptionValidator struct {
+	Description *string `json:"description" validate:"omitempty,max=280"`
+}
+
+func (uidv UpdateIncidentDescriptionValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(uidv); err != nil {
+		return err
+	}
+	return nil
+}
+
+type UpdateIncidentTypeValidator struct {
+	TypeId int64 `json:"type_id" validate:"required"`
+}
+
+func (uitv UpdateIncidentTypeValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(uitv); err != nil {
+		return err
+	}
+	return nil
+}
+
+type CreateTypeValidator struct {
+	Name                        string `json:"name" validate:"required,max=100"`
+	Description                 string `json:"description" validate:"required"`
+	LifetimeWithoutConfirmation int    `json:"lifetime_without_confirmation" validate:"required,gt=0"`
+	NegativeReportsThreshold    int    `json:"negative_reports_threshold" validate:"required,gt=0"`
+	GlobalLifetime              int    `json:"global_lifetime" validate:"required,gt=0,gtefield=LifetimeWithoutConfirmation"`
+	PositiveReportsThreshold    int    `json:"positive_reports_threshold" validate:"required,gt=0"`
+	NeedRecalculation           *bool  `json:"need_recalculation" validate:"required"`
+	// Schéma JSON des attributs des incidents de ce type
+	AttributesSchema map[string]any `json:"attributes_schema"`
+}
+
+func (ctv CreateTypeValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(ctv); err != nil {
+		return err
+	}
+	return nil
+}
+
+type UpdateTypeValidator struct {
+	Name                        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description                 *string `json:"description" validate:"omitempty,min=1"`
+	LifetimeWithoutConfirmation *int    `json:"lifetime_without_confirmation" validate:"omitempty,gt=0"`
+	NegativeReportsThreshold    *int    `json:"negative_reports_threshold" validate:"omitempty,gt=0"`
+	GlobalLifetime              *int    `json:"global_lifetime" validate:"omitempty,gt=0"`
+	PositiveReportsThreshold    *int    `json:"positive_reports_threshold" validate:"omitempty,gt=0"`
+	NeedRecalculation           *bool   `json:"need_recalculation"`
+	Disabled                    *bool   `json:"disabled"`
+	// Schéma JSON des attributs des incidents de ce type, null pour le retirer
+	AttributesSchema helpers.NullObject `json:"attributes_schema" swaggertype:"object"`
+}
+
+func (utv UpdateTypeValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(utv); err != nil {
+		return err
+	}
+	return nil
+}
+
+type GeoJSONLineString struct {
+	Type        string      `json:"type" validate:"required,eq=LineString"`
+	Coordinates [][]float64 `json:"coordinates" validate:"required,min=2,dive,len=2"`
+}
+
+type RouteIncidentsValidator struct {
+	Polyline  string             `json:"polyline" validate:"required_without=Geometry"`
+	Precision int                `json:"precision" validate:"omitempty,oneof=5 6"`
+	Geometry  *GeoJSONLineString `json:"geometry" validate:"required_without=Polyline"`
+	Buffer    float64            `json:"buffer" validate:"required,gt=0,lte=1000"`
+	TypeId    *int64             `json:"type_id"`
+	// Ignore les incidents signalés dans le sens opposé à celui de l'itinéraire
+	SameDirection bool `json:"same_direction"`
+}
+
+func (riv RouteIncidentsValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(riv); err != nil {
+		return err
+	}
+	return nil
+}
+
+const DefaultPageLimit = 20
+
+type PageValidator struct {
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (pv PageValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(pv); err != nil {
+		return err
+	}
+	return nil
+}
+
+type UserHistoryValidator struct {
+	PageValidator
+	From   *time.Time `json:"from"`
+	To     *time.Time `json:"to"`
+	TypeId *int64     `json:"type_id"`
+
+	// Before est la borne exclue déduite d'un paramètre to sans heure
+	Before *time.Time `json:"-"`
+}
+
+func (uhv UserHistoryValidator) Validate() error {
+	validate := validator.New()
+	validate.RegisterStructValidation(validatePeriod, UserHistoryValidator{})
+	if err := validate.Struct(uhv); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validatePeriod(sl validator.StructLevel) {
+	uhv := sl.Current().Interface().(UserHistoryValidator)
+	if uhv.From != nil && uhv.To != nil && uhv.To.Before(*uhv.From) {
+		sl.ReportError(uhv.To, "To", "to", "gtefield", "From")
+	}
+	if uhv.From != nil && uhv.Before != nil && !uhv.Before.After(*uhv.From) {
+		sl.ReportError(uhv.Before, "Before", "to", "gtefield", "From")
+	}
+}
+
+// AreaValidator décrit une zone géographique : une bounding box si min_lat est
+// fourni, sinon un cercle défini par son centre et son rayon en mètres
+type AreaValidator struct {
+	MinLat *float64 `json:"min_lat" validate:"required_with=MinLon MaxLat MaxLon,omitempty,min=-90,max=90"`
+	MinLon *float64 `json:"min_lon" validate:"required_with=MinLat MaxLat MaxLon,omitempty,min=-180,max=180"`
+	MaxLat *float64 `json:"max_lat" validate:"required_with=MinLat MinLon MaxLon,omitempty,min=-90,max=90"`
+	MaxLon *float64 `json:"max_lon" validate:"required_with=MinLat MinLon MaxLat,omitempty,min=-180,max=180"`
+	Lat    *float64 `json:"lat" validate:"required_without=MinLat,omitempty,min=-90,max=90"`
+	Lon    *float64 `json:"lon" validate:"required_without=MinLat,omitempty,min=-180,max=180"`
+	Radius *float64 `json:"radius" validate:"required_without=MinLat,omitempty,gt=0"`
+}
+
+func (av AreaValidator) ToArea() (helpers.Area, error) {
+	if av.MinLat != nil {
+		box := &helpers.BoundingBox{MinLat: *av.MinLat, MinLon: *av.MinLon, MaxLat: *av.MaxLat, MaxLon: *av.MaxLon}
+		if err := box.Validate(); err != nil {
+			return nil, err
+		}
+		return box, nil
+	}
+	return &helpers.Circle{Lat: *av.Lat, Lon: *av.Lon, Radius: *av.Radius}, nil
+}
+
+type WebSocketMessageType string
+
+const (
+	WebSocketSubscribe   WebSocketMessageType = "subscribe"
+	WebSocketUpdateArea  WebSocketMessageType = "update_area"
+	WebSocketUnsubscribe WebSocketMessageType = "unsubscribe"
+	WebSocketPing        WebSocketMessageType = "ping"
+)
+
+type WebSocketMessageValidator struct {
+	Type        WebSocketMessageType `json:"type" validate:"required,oneof=subscribe update_area unsubscribe ping"`
+	Area        *AreaValidator       `json:"area" validate:"required_if=Type subscribe,required_if=Type update_area,omitempty"`
+	LastEventID *uint64              `json:"last_event_id"`
+}
+
+func (wsmv WebSocketMessageValidator) Validate() error {
+	validate := validator.New()
+	if err := validate.Struct(wsmv); err != nil {
+		return err
+	}
+	return nil
+}
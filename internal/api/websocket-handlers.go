@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/json"
+	"github.com/matheodrd/httphelper/handler"
+	"golang.org/x/net/websocket"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/stream"
+	"time"
+)
+
+// webSocketWriteTimeout borne le temps d'envoi d'un message, un client qui ne lit
+// plus sa connexion est ainsi déconnecté au lieu de bloquer indéfiniment
+const webSocketWriteTimeout = 10 * time.Second
+
+// webSocketMaxMessageSize limite la taille des messages envoyés par le client
+const webSocketMaxMessageSize = 4096
+
+// IncidentsWebSocket godoc
+// @Summary Abonnement WebSocket aux incidents d'une zone mobile
+// @Description Ouvre une connexion WebSocket transmettant les événements des incidents de la zone à laquelle le client est abonné.
+// @Description Le client envoie des messages JSON dont le champ type vaut subscribe, update_area, unsubscribe ou ping.
+// @Description Les messages subscribe et update_area contiennent une zone (bounding box ou cercle), subscribe accepte un last_event_id pour reprendre un flux.
+// @Description Le serveur répond par des messages subscribed, area_updated, unsubscribed, pong, event, reset ou error.
+// @Description Les navigateurs ne pouvant pas définir le header Authorization, le token peut être passé avec le paramètre token.
+// @Tags incidents
+// @Security BearerAuth
+// @Param token query string false "Token d'authentification, si le header Authorization ne peut être défini"
+// @Success 101 {object} dto.WebSocketResponseDTO "Connexion WebSocket établie"
+// @Failure 401 {object} AuthError "Utilisateur non authentifié"
+// @Failure 403 {object} AuthError "Session expirée"
+// @Failure 500 {object} InternalErrorResponse "Erreur interne du serveur"
+// @Router /incidents/ws [get]
+func (s *Server) IncidentsWebSocket() http.HandlerFunc {
+	return handler.Handler(func(w http.ResponseWriter, r *http.Request) error {
+		authHeader := r.Header.Get("Authorization")
+		if token := r.URL.Query().Get("token"); authHeader == "" && token != "" {
+			authHeader = "Bearer " + token
+		}
+		if authHeader == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return json.NewEncoder(w).Encode(missingHeader)
+		}
+
+		user, err := s.authenticate(r.Context(), authHeader)
+		if err != nil {
+			writeAuthError(w, err, s.log)
+			return nil
+		}
+
+		server := websocket.Server{
+			// Les origines ne sont pas restreintes, comme pour le reste de l'API (voir WithCORS)
+			Handshake: func(*websocket.Config, *http.Request) error { return nil },
+			Handler: func(conn *websocket.Conn) {
+				conn.MaxPayloadBytes = webSocketMaxMessageSize
+				s.log.Info("websocket client connected", "user", user.ID)
+				session := &webSocketSession{conn: conn, hub: s.hub}
+				session.serve()
+				s.log.Info("websocket client disconnected", "user", user.ID)
+			},
+		}
+		server.ServeHTTP(w, r)
+		return nil
+	})
+}
+
+type webSocketSession struct {
+	conn *websocket.Conn
+	hub  *stream.Hub
+	sub  *stream.Subscription
+}
+
+// serve traite les messages du client et les événements du hub dans une même goroutine,
+// seule à écrire sur la connexion, jusqu'à la déconnexion du client
+func (ws *webSocketSession) serve() {
+	defer func() {
+		if ws.sub != nil {
+			ws.hub.Unsubscribe(ws.sub)
+		}
+		_ = ws.conn.Close()
+	}()
+
+	messages := make(chan []byte)
+	closed := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(closed)
+		for {
+			var raw []byte
+			if err := websocket.Message.Receive(ws.conn, &raw); err != nil {
+				return
+			}
+			select {
+			case messages <- raw:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	for {
+		var events <-chan stream.Event
+		if ws.sub != nil {
+			events = ws.sub.Events
+		}
+
+		var err error
+		select {
+		case <-closed:
+			return
+		case raw := <-messages:
+			err = ws.handle(raw)
+		case event, ok := <-events:
+			if ok {
+				err = ws.sendEvent(&event)
+			} else {
+				// Le hub a déconnecté l'abonnement car le client ne suivait pas le rythme
+				ws.sub = nil
+				err = ws.sendError("client is too slow, subscribe again with last_event_id to resume")
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (ws *webSocketSession) handle(raw []byte) error {
+	var message validations.WebSocketMessageValidator
+	if err := json.Unmarshal(raw, &message); err != nil {
+		return ws.sendError("invalid message: " + err.Error())
+	}
+	if err := message.Validate(); err != nil {
+		return ws.sendError(err.Error())
+	}
+
+	switch message.Type {
+	case validations.WebSocketSubscribe:
+		area, err := message.Area.ToArea()
+		if err != nil {
+			return ws.sendError(err.Error())
+		}
+		if ws.sub != nil {
+			ws.hub.Unsubscribe(ws.sub)
+		}
+
+		sub, missed, complete := ws.hub.Subscribe(area, message.LastEventID)
+		ws.sub = sub
+
+		if err := ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketSubscribed}); err != nil {
+			return err
+		}
+		if !complete {
+			if err := ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketReset}); err != nil {
+				return err
+			}
+		}
+		for _, event := range missed {
+			if err := ws.sendEvent(&event); err != nil {
+				return err
+			}
+		}
+		return nil
+	case validations.WebSocketUpdateArea:
+		if ws.sub == nil {
+			return ws.sendError("not subscribed")
+		}
+		area, err := message.Area.ToArea()
+		if err != nil {
+			return ws.sendError(err.Error())
+		}
+		ws.hub.UpdateArea(ws.sub, area)
+		return ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketAreaUpdated})
+	case validations.WebSocketUnsubscribe:
+		if ws.sub != nil {
+			ws.hub.Unsubscribe(ws.sub)
+			ws.sub = nil
+		}
+		return ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketUnsubscribed})
+	case validations.WebSocketPing:
+		return ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketPong})
+	}
+
+	return nil
+}
+
+func (ws *webSocketSession) sendEvent(event *stream.Event) error {
+	return ws.send(&dto.WebSocketResponseDTO{
+		Type:   dto.WebSocketEvent,
+		ID:     event.ID,
+		Action: string(event.Message.Action),
+		Data:   &event.Message.Data,
+	})
+}
+
+func (ws *webSocketSession) sendError(message string) error {
+	return ws.send(&dto.WebSocketResponseDTO{Type: dto.WebSocketError, Error: message})
+}
+
+func (ws *webSocketSession) send(response *dto.WebSocketResponseDTO) error {
+	if err := ws.conn.SetWriteDeadline(time.Now().Add(webSocketWriteTimeout)); err != nil {
+		return err
+	}
+	return websocket.JSON.Send(ws.conn, response)
+}
@@ -3,10 +3,21 @@ package config
 import (
 	"fmt"
 	"github.com/caarlos0/env/v11"
+	"strings"
+	"time"
 )
 
 var UsersBaseUrl string
 
+// PhotosBaseUrl est le préfixe des URLs des photos exposées par l'API
+var PhotosBaseUrl string
+
+// Modes d'authentification : vérification par le service users ou vérification locale des JWT
+const (
+	AuthModeRemote = "remote"
+	AuthModeLocal  = "local"
+)
+
 type Config struct {
 	ENV             string `env:"ENV" envDefault:"production"`
 	DbUrl           string `env:"DB_URL"`
@@ -16,6 +27,56 @@ type Config struct {
 	RedisHost       string `env:"REDIS_HOST"`
 	RedisPort       string `env:"REDIS_PORT"`
 	IncidentChannel string `env:"REDIS_INCIDENTS_CHANNEL" envDefault:"incidents"`
+	TypesChannel    string `env:"REDIS_INCIDENT_TYPES_CHANNEL" envDefault:"incident-types"`
+	BboxMaxResults  int    `env:"BBOX_MAX_RESULTS" envDefault:"500"`
+	SyncMaxResults  int    `env:"SYNC_MAX_RESULTS" envDefault:"500"`
+	ClustersMaxZoom int    `env:"CLUSTERS_MAX_ZOOM" envDefault:"14"`
+
+	HeadingTolerance float64 `env:"HEADING_TOLERANCE" envDefault:"60"`
+
+	SeverityLifetimeFactors map[string]float64 `env:"SEVERITY_LIFETIME_FACTORS" envSeparator:"," envKeyValSeparator:":"`
+
+	UsersTimeout    time.Duration `env:"SUPMAP_USERS_TIMEOUT" envDefault:"2s"`
+	UsersCacheTTL   time.Duration `env:"USERS_CACHE_TTL" envDefault:"5m"`
+	UsersCacheRedis bool          `env:"USERS_CACHE_REDIS" envDefault:"false"`
+
+	AuthCacheTTL            time.Duration `env:"AUTH_CACHE_TTL" envDefault:"30s"`
+	AuthInvalidationChannel string        `env:"REDIS_AUTH_INVALIDATION_CHANNEL" envDefault:"auth-invalidation"`
+
+	AuthMode           string        `env:"AUTH_MODE" envDefault:"remote"`
+	AuthJwksUrl        string        `env:"AUTH_JWKS_URL"`
+	AuthJwksFile       string        `env:"AUTH_JWKS_FILE"`
+	AuthJwksRefresh    time.Duration `env:"AUTH_JWKS_REFRESH_INTERVAL" envDefault:"1h"`
+	AuthJwtIssuer      string        `env:"AUTH_JWT_ISSUER"`
+	AuthJwtAudience    string        `env:"AUTH_JWT_AUDIENCE"`
+	AuthRemoteFallback bool          `env:"AUTH_REMOTE_FALLBACK" envDefault:"false"`
+	AuthJwtMaxLifetime time.Duration `env:"AUTH_JWT_MAX_LIFETIME" envDefault:"24h"`
+
+	StreamHeartbeat   time.Duration `env:"STREAM_HEARTBEAT_INTERVAL" envDefault:"15s"`
+	StreamHistorySize int           `env:"STREAM_HISTORY_SIZE" envDefault:"1000"`
+
+	TilesCacheTTL    time.Duration `env:"TILES_CACHE_TTL" envDefault:"1m"`
+	TilesCacheSize   int           `env:"TILES_CACHE_SIZE" envDefault:"2000"`
+	TilesMaxFeatures int           `env:"TILES_MAX_FEATURES" envDefault:"5000"`
+
+	DescriptionEditWindow time.Duration `env:"DESCRIPTION_EDIT_WINDOW" envDefault:"10m"`
+	ProfanityWords        []string      `env:"PROFANITY_WORDS" envSeparator:","`
+	ProfanityWordsFile    string        `env:"PROFANITY_WORDS_FILE"`
+
+	PhotosStorage       string `env:"PHOTOS_STORAGE" envDefault:"local"`
+	PhotosDir           string `env:"PHOTOS_DIR" envDefault:"photos"`
+	PhotosPublicUrl     string `env:"PHOTOS_PUBLIC_URL" envDefault:"/incidents/photos"`
+	PhotosMaxSize       int64  `env:"PHOTOS_MAX_SIZE" envDefault:"5242880"`
+	PhotosMaxCount      int    `env:"PHOTOS_MAX_COUNT" envDefault:"3"`
+	PhotosMaxDimension  int    `env:"PHOTOS_MAX_DIMENSION" envDefault:"2048"`
+	PhotosThumbnailSize int    `env:"PHOTOS_THUMBNAIL_SIZE" envDefault:"320"`
+
+	S3Endpoint  string        `env:"S3_ENDPOINT"`
+	S3Region    string        `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket    string        `env:"S3_BUCKET"`
+	S3AccessKey string        `env:"S3_ACCESS_KEY"`
+	S3SecretKey string        `env:"S3_SECRET_KEY"`
+	S3Timeout   time.Duration `env:"S3_TIMEOUT" envDefault:"30s"`
 }
 
 func New() (*Config, error) {
@@ -23,10 +84,15 @@ func New() (*Config, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
+	// L'intervalle est utilisé par un time.Ticker qui n'accepte pas de durée nulle ou négative
+	if cfg.StreamHeartbeat <= 0 {
+		return nil, fmt.Errorf("failed to load config: STREAM_HEARTBEAT_INTERVAL must be positive, got %s", cfg.StreamHeartbeat)
+	}
 	exposeUrls(&cfg)
 	return &cfg, nil
 }
 
 func exposeUrls(config *Config) {
 	UsersBaseUrl = fmt.Sprintf("http://%s:%s", config.UsersHost, config.UsersPort)
+	PhotosBaseUrl = strings.TrimSuffix(config.PhotosPublicUrl, "/")
 }
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestNewStreamHeartbeat(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expectErr bool
+	}{
+		{name: "default", value: "", expectErr: false},
+		{name: "positive", value: "5s", expectErr: false},
+		{name: "zero", value: "0s", expectErr: true},
+		{name: "negative", value: "-1s", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value != "" {
+				t.Setenv("STREAM_HEARTBEAT_INTERVAL", tt.value)
+			}
+
+			cfg, err := New()
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected an error for %q", tt.value)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.StreamHeartbeat <= 0 {
+				t.Errorf("expected a positive interval, got %s", cfg.StreamHeartbeat)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cursor identifie la position d'un élément dans une liste triée par date de création puis par ID.
+// Il est transmis aux clients sous forme opaque.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+func (c *Cursor) Encode() string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + strconv.FormatInt(c.ID, 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(encoded string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	createdAt, id, found := strings.Cut(string(raw), "|")
+	if !found {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if cursor.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.ID, err = strconv.ParseInt(id, 10, 64); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -0,0 +1,159 @@
+package helpers
+
+import (
+	"errors"
+	"math"
+)
+
+// BoundingBox représente une zone rectangulaire en coordonnées WGS84.
+// Lorsque MinLon est supérieur à MaxLon, la zone traverse l'antiméridien (180°).
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+func (b *BoundingBox) Validate() error {
+	if b.MinLat < -90 || b.MinLat > 90 || b.MaxLat < -90 || b.MaxLat > 90 {
+		return errors.New("latitudes must be between -90 and 90")
+	}
+	if b.MinLon < -180 || b.MinLon > 180 || b.MaxLon < -180 || b.MaxLon > 180 {
+		return errors.New("longitudes must be between -180 and 180")
+	}
+	if b.MinLat > b.MaxLat {
+		return errors.New("min_lat must be lower than or equal to max_lat")
+	}
+	return nil
+}
+
+// CrossesAntimeridian indique si la zone chevauche la longitude 180°
+func (b *BoundingBox) CrossesAntimeridian() bool {
+	return b.MinLon > b.MaxLon
+}
+
+func (b *BoundingBox) Contains(lat, lon float64) bool {
+	if lat < b.MinLat || lat > b.MaxLat {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return lon >= b.MinLon || lon <= b.MaxLon
+	}
+	return lon >= b.MinLon && lon <= b.MaxLon
+}
+
+const EarthRadius = 6371000.0
+
+// Area est une zone géographique dans laquelle on peut tester l'appartenance d'un point
+type Area interface {
+	Contains(lat, lon float64) bool
+}
+
+// Circle représente une zone circulaire de Radius mètres autour d'un point
+type Circle struct {
+	Lat    float64
+	Lon    float64
+	Radius float64
+}
+
+func (c *Circle) Validate() error {
+	if c.Lat < -90 || c.Lat > 90 {
+		return errors.New("lat must be between -90 and 90")
+	}
+	if c.Lon < -180 || c.Lon > 180 {
+		return errors.New("lon must be between -180 and 180")
+	}
+	if c.Radius <= 0 {
+		return errors.New("radius must be greater than 0")
+	}
+	return nil
+}
+
+func (c *Circle) Contains(lat, lon float64) bool {
+	return Distance(c.Lat, c.Lon, lat, lon) <= c.Radius
+}
+
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Distance calcule la distance en mètres entre deux points avec la formule de haversine
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLon := toRadians(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// ExpandBoundingBox agrandit la zone de margin mètres dans toutes les directions.
+// Une zone agrandie au-delà de 180° de longitude n'est pas tronquée mais déborde de l'autre côté
+// de l'antiméridien (MinLon supérieur à MaxLon), voir Split.
+func ExpandBoundingBox(box BoundingBox, margin float64) BoundingBox {
+	dLat := margin / EarthRadius * 180 / math.Pi
+
+	// Un degré de longitude est d'autant plus court que l'on s'éloigne de l'équateur
+	maxAbsLat := math.Min(89, math.Max(math.Abs(box.MinLat), math.Abs(box.MaxLat)))
+	dLon := dLat / math.Cos(toRadians(maxAbsLat))
+
+	width := box.MaxLon - box.MinLon
+	if box.CrossesAntimeridian() {
+		width += 360
+	}
+
+	expanded := BoundingBox{
+		MinLat: math.Max(-90, box.MinLat-dLat),
+		MinLon: -180,
+		MaxLat: math.Min(90, box.MaxLat+dLat),
+		MaxLon: 180,
+	}
+
+	if width+2*dLon < 360 {
+		expanded.MinLon = normalizeLongitude(box.MinLon - dLon)
+		expanded.MaxLon = normalizeLongitude(box.MaxLon + dLon)
+	}
+
+	return expanded
+}
+
+// Split découpe une zone qui traverse l'antiméridien en deux zones de part et d'autre de 180°.
+// Une zone qui ne le traverse pas est retournée telle quelle.
+func (b BoundingBox) Split() []BoundingBox {
+	if !b.CrossesAntimeridian() {
+		return []BoundingBox{b}
+	}
+
+	return []BoundingBox{
+		{MinLat: b.MinLat, MinLon: b.MinLon, MaxLat: b.MaxLat, MaxLon: 180},
+		{MinLat: b.MinLat, MinLon: -180, MaxLat: b.MaxLat, MaxLon: b.MaxLon},
+	}
+}
+
+// normalizeLongitude ramène une longitude dans l'intervalle [-180, 180]
+func normalizeLongitude(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+	return math.Mod(math.Mod(lon+180, 360)+360, 360) - 180
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// HeadingDifference retourne l'écart angulaire en degrés (0 à 180) entre deux caps
+func HeadingDifference(a, b float64) float64 {
+	diff := math.Abs(normalizeHeading(a) - normalizeHeading(b))
+	return math.Min(diff, 360-diff)
+}
+
+func normalizeHeading(heading float64) float64 {
+	heading = math.Mod(heading, 360)
+	if heading < 0 {
+		heading += 360
+	}
+	return heading
+}
@@ -0,0 +1,120 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestExpandBoundingBox(t *testing.T) {
+	degree := EarthRadius * math.Pi / 180
+	// Près des pôles, l'écart en longitude est calculé à 89° de latitude
+	polarLon := 1 / math.Cos(89*math.Pi/180)
+
+	tests := []struct {
+		name     string
+		box      BoundingBox
+		margin   float64
+		expected BoundingBox
+	}{
+		{
+			name:     "without margin",
+			box:      BoundingBox{MinLat: 1, MinLon: 2, MaxLat: 3, MaxLon: 4},
+			margin:   0,
+			expected: BoundingBox{MinLat: 1, MinLon: 2, MaxLat: 3, MaxLon: 4},
+		},
+		{
+			name:     "at the equator",
+			box:      BoundingBox{MinLat: 0, MinLon: 0, MaxLat: 0, MaxLon: 0},
+			margin:   degree,
+			expected: BoundingBox{MinLat: -1, MinLon: -1, MaxLat: 1, MaxLon: 1},
+		},
+		{
+			name:     "latitudes are clamped at the poles",
+			box:      BoundingBox{MinLat: 89.5, MinLon: 0, MaxLat: 89.5, MaxLon: 0},
+			margin:   degree,
+			expected: BoundingBox{MinLat: 88.5, MinLon: -polarLon, MaxLat: 90, MaxLon: polarLon},
+		},
+		{
+			name:     "east edge wraps across the antimeridian",
+			box:      BoundingBox{MinLat: 0, MinLon: 179.5, MaxLat: 0, MaxLon: 179.5},
+			margin:   degree,
+			expected: BoundingBox{MinLat: -1, MinLon: 178.5, MaxLat: 1, MaxLon: -179.5},
+		},
+		{
+			name:     "west edge wraps across the antimeridian",
+			box:      BoundingBox{MinLat: 0, MinLon: -179.5, MaxLat: 0, MaxLon: -179.5},
+			margin:   degree,
+			expected: BoundingBox{MinLat: -1, MinLon: 179.5, MaxLat: 1, MaxLon: -178.5},
+		},
+		{
+			name:     "box already crossing the antimeridian",
+			box:      BoundingBox{MinLat: 0, MinLon: 179, MaxLat: 0, MaxLon: -179},
+			margin:   degree,
+			expected: BoundingBox{MinLat: -1, MinLon: 178, MaxLat: 1, MaxLon: -178},
+		},
+		{
+			name:     "box covering every longitude",
+			box:      BoundingBox{MinLat: 0, MinLon: -179.5, MaxLat: 0, MaxLon: 179.5},
+			margin:   degree,
+			expected: BoundingBox{MinLat: -1, MinLon: -180, MaxLat: 1, MaxLon: 180},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if box := ExpandBoundingBox(tt.box, tt.margin); !sameBox(box, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, box)
+			}
+		})
+	}
+}
+
+func TestBoundingBoxSplit(t *testing.T) {
+	box := BoundingBox{MinLat: -1, MinLon: 10, MaxLat: 1, MaxLon: 20}
+	if parts := box.Split(); len(parts) != 1 || parts[0] != box {
+		t.Errorf("expected the box itself, got %v", parts)
+	}
+
+	crossing := BoundingBox{MinLat: -1, MinLon: 170, MaxLat: 1, MaxLon: -170}
+	parts := crossing.Split()
+	expected := []BoundingBox{
+		{MinLat: -1, MinLon: 170, MaxLat: 1, MaxLon: 180},
+		{MinLat: -1, MinLon: -180, MaxLat: 1, MaxLon: -170},
+	}
+	if len(parts) != 2 || parts[0] != expected[0] || parts[1] != expected[1] {
+		t.Errorf("expected %v, got %v", expected, parts)
+	}
+
+	for _, lon := range []float64{175, 180, -180, -175} {
+		if !crossing.Contains(0, lon) || (!parts[0].Contains(0, lon) && !parts[1].Contains(0, lon)) {
+			t.Errorf("expected longitude %f to be contained", lon)
+		}
+	}
+}
+
+func TestHeadingDifference(t *testing.T) {
+	tests := []struct {
+		a, b float64
+		want float64
+	}{
+		{0, 0, 0},
+		{10, 50, 40},
+		{50, 10, 40},
+		{0, 180, 180},
+		{90, 270, 180},
+		{350, 10, 20},
+		{10, 350, 20},
+		{0, 360, 0},
+		{359, 1, 2},
+		{-10, 10, 20},
+		{-90, 270, 0},
+		{720, 30, 30},
+		{181, 0, 179},
+	}
+
+	for _, tt := range tests {
+		if got := HeadingDifference(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("HeadingDifference(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
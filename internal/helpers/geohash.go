@@ -0,0 +1,65 @@
+package helpers
+
+import "strings"
+
+// GeohashPrecision est le nombre de caractères des geohash enregistrés, soit des cellules
+// d'environ 5 mètres de côté. Les préfixes du geohash correspondent aux cellules plus grandes
+// qui le contiennent, une seule colonne suffit donc pour toutes les précisions.
+const GeohashPrecision = 9
+
+const geohashBase32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// IsGeohash indique si la chaîne est un geohash d'au plus GeohashPrecision caractères
+func IsGeohash(hash string) bool {
+	if hash == "" || len(hash) > GeohashPrecision {
+		return false
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune(geohashBase32, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// EncodeGeohash retourne le geohash du point sur precision caractères.
+// L'algorithme est identique à celui de la migration qui initialise la colonne geohash.
+func EncodeGeohash(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	var hash strings.Builder
+	bits, bitCount := 0, 0
+	even := true
+	for hash.Len() < precision {
+		// Les bits pairs découpent la longitude, les bits impairs la latitude
+		if even {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				bits = bits*2 + 1
+				minLon = mid
+			} else {
+				bits = bits * 2
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				bits = bits*2 + 1
+				minLat = mid
+			} else {
+				bits = bits * 2
+				maxLat = mid
+			}
+		}
+		even = !even
+
+		bitCount++
+		if bitCount == 5 {
+			hash.WriteByte(geohashBase32[bits])
+			bits, bitCount = 0, 0
+		}
+	}
+
+	return hash.String()
+}
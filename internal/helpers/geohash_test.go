@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"testing"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	tests := []struct {
+		name      string
+		lat, lon  float64
+		precision int
+		expected  string
+	}{
+		{name: "Jutland", lat: 57.64911, lon: 10.40744, precision: 9, expected: "u4pruydqq"},
+		{name: "Jutland, shorter precision", lat: 57.64911, lon: 10.40744, precision: 5, expected: "u4pru"},
+		{name: "Jutland, longer precision", lat: 57.64911, lon: 10.40744, precision: 11, expected: "u4pruydqqvj"},
+		{name: "Paris", lat: 48.8566, lon: 2.3522, precision: 6, expected: "u09tvw"},
+		{name: "New York", lat: 40.7128, lon: -74.0060, precision: 6, expected: "dr5reg"},
+		{name: "Sydney", lat: -33.8688, lon: 151.2093, precision: 6, expected: "r3gx2f"},
+		{name: "origin", lat: 0, lon: 0, precision: 5, expected: "s0000"},
+		{name: "south west corner", lat: -90, lon: -180, precision: 5, expected: "00000"},
+		{name: "north east corner", lat: 90, lon: 180, precision: 5, expected: "zzzzz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if hash := EncodeGeohash(tt.lat, tt.lon, tt.precision); hash != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, hash)
+			}
+		})
+	}
+}
+
+func TestIsGeohash(t *testing.T) {
+	tests := []struct {
+		hash     string
+		expected bool
+	}{
+		{hash: "u", expected: true},
+		{hash: "u4pruydqq", expected: true},
+		{hash: "", expected: false},
+		{hash: "u4pruydqqv", expected: false},
+		{hash: "u4pa", expected: false},
+		{hash: "U4PR", expected: false},
+		{hash: "u4%", expected: false},
+		{hash: "u4_r", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.hash, func(t *testing.T) {
+			if IsGeohash(tt.hash) != tt.expected {
+				t.Errorf("expected %t for %q", tt.expected, tt.hash)
+			}
+		})
+	}
+}
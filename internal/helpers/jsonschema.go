@@ -0,0 +1,332 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema est un sous-ensemble de JSON Schema suffisant pour décrire les attributs des incidents :
+// type, properties, required, additionalProperties, enum, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, minLength, maxLength, pattern, items, minItems et maxItems.
+// Les annotations (title, description, default, examples, format...) sont acceptées mais ignorées,
+// les autres mots-clés sont refusés pour ne pas laisser croire qu'ils sont vérifiés.
+type JSONSchema struct {
+	types                []string
+	properties           map[string]*JSONSchema
+	required             []string
+	additionalProperties *JSONSchema
+	noAdditional         bool
+	enum                 []any
+	minimum              *float64
+	maximum              *float64
+	exclusiveMinimum     *float64
+	exclusiveMaximum     *float64
+	minLength            *int
+	maxLength            *int
+	pattern              *regexp.Regexp
+	items                *JSONSchema
+	minItems             *int
+	maxItems             *int
+}
+
+// SchemaError indique le mot-clé invalide d'un schéma, au format JSON Pointer
+type SchemaError struct {
+	Path string
+	Err  error
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("invalid schema at %s: %s", e.Path, e.Err)
+}
+
+var schemaTypes = []string{"object", "array", "string", "number", "integer", "boolean", "null"}
+
+var schemaAnnotations = []string{"$schema", "$id", "$comment", "title", "description", "default", "examples", "format", "readOnly", "writeOnly", "deprecated"}
+
+// CompileJSONSchema vérifie le schéma, dont la racine doit décrire un objet, et le prépare pour la validation
+func CompileJSONSchema(schema map[string]any) (*JSONSchema, error) {
+	compiled, err := compileSchema(schema, "")
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Equal(compiled.types, []string{"object"}) {
+		return nil, errors.New(`schema root must have type "object"`)
+	}
+	return compiled, nil
+}
+
+func compileSchema(schema map[string]any, path string) (*JSONSchema, error) {
+	compiled := &JSONSchema{}
+	for keyword, value := range schema {
+		var err error
+		switch keyword {
+		case "type":
+			compiled.types, err = compileTypes(value)
+		case "properties":
+			compiled.properties, err = compileProperties(value, path)
+		case "required":
+			compiled.required, err = toStrings(value)
+		case "additionalProperties":
+			switch v := value.(type) {
+			case bool:
+				compiled.noAdditional = !v
+			case map[string]any:
+				compiled.additionalProperties, err = compileSchema(v, path+"/additionalProperties")
+			default:
+				err = errors.New("must be a boolean or a schema")
+			}
+		case "enum":
+			values, ok := value.([]any)
+			if !ok || len(values) == 0 {
+				err = errors.New("must be a non-empty array")
+			}
+			compiled.enum = values
+		case "minimum":
+			compiled.minimum, err = toNumber(value)
+		case "maximum":
+			compiled.maximum, err = toNumber(value)
+		case "exclusiveMinimum":
+			compiled.exclusiveMinimum, err = toNumber(value)
+		case "exclusiveMaximum":
+			compiled.exclusiveMaximum, err = toNumber(value)
+		case "minLength":
+			compiled.minLength, err = toCount(value)
+		case "maxLength":
+			compiled.maxLength, err = toCount(value)
+		case "minItems":
+			compiled.minItems, err = toCount(value)
+		case "maxItems":
+			compiled.maxItems, err = toCount(value)
+		case "pattern":
+			pattern, ok := value.(string)
+			if !ok {
+				err = errors.New("must be a string")
+				break
+			}
+			compiled.pattern, err = regexp.Compile(pattern)
+		case "items":
+			items, ok := value.(map[string]any)
+			if !ok {
+				err = errors.New("must be a schema")
+				break
+			}
+			compiled.items, err = compileSchema(items, path+"/items")
+		default:
+			if !slices.Contains(schemaAnnotations, keyword) {
+				err = errors.New("unsupported keyword")
+			}
+		}
+		var schemaErr *SchemaError
+		if errors.As(err, &schemaErr) {
+			return nil, err
+		} else if err != nil {
+			return nil, &SchemaError{Path: path + "/" + keyword, Err: err}
+		}
+	}
+	return compiled, nil
+}
+
+func compileTypes(value any) ([]string, error) {
+	var types []string
+	switch v := value.(type) {
+	case string:
+		types = []string{v}
+	case []any:
+		var err error
+		if types, err = toStrings(v); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("must be a string or an array of strings")
+	}
+
+	for _, t := range types {
+		if !slices.Contains(schemaTypes, t) {
+			return nil, fmt.Errorf("unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func compileProperties(value any, path string) (map[string]*JSONSchema, error) {
+	properties, ok := value.(map[string]any)
+	if !ok {
+		return nil, errors.New("must be an object")
+	}
+
+	compiled := make(map[string]*JSONSchema, len(properties))
+	for name, property := range properties {
+		schema, ok := property.(map[string]any)
+		if !ok {
+			return nil, &SchemaError{Path: path + "/properties/" + escapePointer(name), Err: errors.New("must be a schema")}
+		}
+		var err error
+		if compiled[name], err = compileSchema(schema, path+"/properties/"+escapePointer(name)); err != nil {
+			return nil, err
+		}
+	}
+	return compiled, nil
+}
+
+func toStrings(value any) ([]string, error) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, errors.New("must be an array of strings")
+	}
+	result := make([]string, len(values))
+	for i, v := range values {
+		if result[i], ok = v.(string); !ok {
+			return nil, errors.New("must be an array of strings")
+		}
+	}
+	return result, nil
+}
+
+func toNumber(value any) (*float64, error) {
+	number, ok := value.(float64)
+	if !ok {
+		return nil, errors.New("must be a number")
+	}
+	return &number, nil
+}
+
+func toCount(value any) (*int, error) {
+	number, ok := value.(float64)
+	if !ok || number < 0 || number != math.Trunc(number) {
+		return nil, errors.New("must be a non-negative integer")
+	}
+	count := int(number)
+	return &count, nil
+}
+
+// Validate vérifie une valeur décodée depuis du JSON. Les erreurs sont indexées par le chemin
+// de la valeur en faute, au format JSON Pointer ("/lanes_blocked"), et la map est vide si la valeur est valide.
+func (s *JSONSchema) Validate(value any) map[string]string {
+	errs := make(map[string]string)
+	s.validate(value, "", errs)
+	return errs
+}
+
+func (s *JSONSchema) validate(value any, path string, errs map[string]string) {
+	fail := func(format string, args ...any) {
+		key := path
+		if key == "" {
+			key = "/"
+		}
+		addSchemaError(errs, key, fmt.Sprintf(format, args...))
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(value, t) }) {
+		fail("must be of type %s", strings.Join(s.types, " or "))
+		return
+	}
+
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(v any) bool { return reflect.DeepEqual(v, value) }) {
+		fail("must be one of the allowed values")
+		return
+	}
+
+	switch v := value.(type) {
+	case float64:
+		switch {
+		case s.minimum != nil && v < *s.minimum:
+			fail("must be greater than or equal to %v", *s.minimum)
+		case s.maximum != nil && v > *s.maximum:
+			fail("must be less than or equal to %v", *s.maximum)
+		case s.exclusiveMinimum != nil && v <= *s.exclusiveMinimum:
+			fail("must be greater than %v", *s.exclusiveMinimum)
+		case s.exclusiveMaximum != nil && v >= *s.exclusiveMaximum:
+			fail("must be less than %v", *s.exclusiveMaximum)
+		}
+	case string:
+		length := utf8.RuneCountInString(v)
+		switch {
+		case s.minLength != nil && length < *s.minLength:
+			fail("must be at least %d characters long", *s.minLength)
+		case s.maxLength != nil && length > *s.maxLength:
+			fail("must be at most %d characters long", *s.maxLength)
+		case s.pattern != nil && !s.pattern.MatchString(v):
+			fail("must match pattern %s", s.pattern.String())
+		}
+	case []any:
+		switch {
+		case s.minItems != nil && len(v) < *s.minItems:
+			fail("must contain at least %d items", *s.minItems)
+		case s.maxItems != nil && len(v) > *s.maxItems:
+			fail("must contain at most %d items", *s.maxItems)
+		}
+		if s.items != nil {
+			for i, item := range v {
+				s.items.validate(item, fmt.Sprintf("%s/%d", path, i), errs)
+			}
+		}
+	case map[string]any:
+		for _, name := range s.required {
+			if _, ok := v[name]; !ok {
+				addSchemaError(errs, path+"/"+escapePointer(name), "is required")
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			childPath := path + "/" + escapePointer(name)
+			if property, ok := s.properties[name]; ok {
+				property.validate(v[name], childPath, errs)
+			} else if s.additionalProperties != nil {
+				s.additionalProperties.validate(v[name], childPath, errs)
+			} else if s.noAdditional {
+				addSchemaError(errs, childPath, "is not allowed")
+			}
+		}
+	}
+}
+
+// addSchemaError conserve la première erreur de chaque valeur
+func addSchemaError(errs map[string]string, key string, message string) {
+	if _, exists := errs[key]; !exists {
+		errs[key] = message
+	}
+}
+
+func hasType(value any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		number, ok := value.(float64)
+		return ok && number == math.Trunc(number) && !math.IsInf(number, 0)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "null":
+		return value == nil
+	}
+	return false
+}
+
+// escapePointer échappe un nom de propriété pour un JSON Pointer (RFC 6901)
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
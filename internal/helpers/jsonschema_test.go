@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"testing"
+)
+
+// decodeJSON décode un document JSON comme le font les handlers, les nombres devenant des float64
+func decodeJSON(t *testing.T, document string) any {
+	t.Helper()
+	var value any
+	if err := json.Unmarshal([]byte(document), &value); err != nil {
+		t.Fatalf("invalid test document %s: %v", document, err)
+	}
+	return value
+}
+
+func TestCompileJSONSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		wantErr string
+	}{
+		{"empty object schema", `{"type": "object"}`, ""},
+		{"annotations", `{"$schema": "https://json-schema.org/draft/2020-12/schema", "title": "Bouchon", "description": "Attributs", "type": "object", "properties": {"lanes": {"type": "integer", "default": 1, "examples": [1, 2], "format": "int32", "deprecated": false}}}`, ""},
+		{"every supported keyword", `{
+			"type": "object",
+			"required": ["lanes"],
+			"additionalProperties": false,
+			"properties": {
+				"lanes": {"type": "integer", "minimum": 1, "maximum": 6},
+				"speed": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 130},
+				"cause": {"type": "string", "enum": ["accident", "works"]},
+				"plate": {"type": "string", "minLength": 2, "maxLength": 10, "pattern": "^[A-Z0-9-]+$"},
+				"lanes_list": {"type": "array", "items": {"type": "integer"}, "minItems": 1, "maxItems": 4},
+				"extra": {"type": "object", "additionalProperties": {"type": "string"}},
+				"nullable": {"type": ["string", "null"]}
+			}
+		}`, ""},
+		{"root without type", `{"properties": {}}`, `schema root must have type "object"`},
+		{"root of another type", `{"type": "string"}`, `schema root must have type "object"`},
+		{"root with several types", `{"type": ["object", "null"]}`, `schema root must have type "object"`},
+		{"unknown type", `{"type": "object", "properties": {"a": {"type": "date"}}}`, `invalid schema at /properties/a/type: unknown type "date"`},
+		{"type of the wrong kind", `{"type": 1}`, "invalid schema at /type: must be a string or an array of strings"},
+		{"type array with a number", `{"type": ["object", 1]}`, "invalid schema at /type: must be an array of strings"},
+		{"unsupported keyword", `{"type": "object", "oneOf": []}`, "invalid schema at /oneOf: unsupported keyword"},
+		{"nested unsupported keyword", `{"type": "object", "properties": {"a": {"$ref": "#/defs/a"}}}`, "invalid schema at /properties/a/$ref: unsupported keyword"},
+		{"properties not an object", `{"type": "object", "properties": []}`, "invalid schema at /properties: must be an object"},
+		{"property not a schema", `{"type": "object", "properties": {"a": true}}`, "invalid schema at /properties/a: must be a schema"},
+		{"escaped property name", `{"type": "object", "properties": {"a/b~c": {"minimum": "1"}}}`, "invalid schema at /properties/a~1b~0c/minimum: must be a number"},
+		{"required not strings", `{"type": "object", "required": ["a", 1]}`, "invalid schema at /required: must be an array of strings"},
+		{"additionalProperties of the wrong kind", `{"type": "object", "additionalProperties": "no"}`, "invalid schema at /additionalProperties: must be a boolean or a schema"},
+		{"invalid additionalProperties schema", `{"type": "object", "additionalProperties": {"type": "nope"}}`, `invalid schema at /additionalProperties/type: unknown type "nope"`},
+		{"empty enum", `{"type": "object", "properties": {"a": {"enum": []}}}`, "invalid schema at /properties/a/enum: must be a non-empty array"},
+		{"enum not an array", `{"type": "object", "properties": {"a": {"enum": "a"}}}`, "invalid schema at /properties/a/enum: must be a non-empty array"},
+		{"string maximum", `{"type": "object", "properties": {"a": {"maximum": "10"}}}`, "invalid schema at /properties/a/maximum: must be a number"},
+		{"negative minLength", `{"type": "object", "properties": {"a": {"minLength": -1}}}`, "invalid schema at /properties/a/minLength: must be a non-negative integer"},
+		{"fractional maxItems", `{"type": "object", "properties": {"a": {"maxItems": 1.5}}}`, "invalid schema at /properties/a/maxItems: must be a non-negative integer"},
+		{"pattern not a string", `{"type": "object", "properties": {"a": {"pattern": 1}}}`, "invalid schema at /properties/a/pattern: must be a string"},
+		{"invalid pattern", `{"type": "object", "properties": {"a": {"pattern": "("}}}`, "invalid schema at /properties/a/pattern: error parsing regexp: missing closing ): `(`"},
+		{"items not a schema", `{"type": "object", "properties": {"a": {"items": [{"type": "string"}]}}}`, "invalid schema at /properties/a/items: must be a schema"},
+		{"invalid items schema", `{"type": "object", "properties": {"a": {"items": {"minItems": "2"}}}}`, "invalid schema at /properties/a/items/minItems: must be a non-negative integer"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, _ := decodeJSON(t, tt.schema).(map[string]any)
+			compiled, err := CompileJSONSchema(schema)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if compiled == nil {
+					t.Fatal("expected a compiled schema")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected error %q", tt.wantErr)
+			}
+			if err.Error() != tt.wantErr {
+				t.Errorf("expected error %q, got %q", tt.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestCompileJSONSchemaErrorPath(t *testing.T) {
+	schema := decodeJSON(t, `{"type": "object", "properties": {"a": {"type": "object", "properties": {"b": {"maximum": true}}}}}`).(map[string]any)
+	_, err := CompileJSONSchema(schema)
+
+	var schemaErr *SchemaError
+	if !errors.As(err, &schemaErr) {
+		t.Fatalf("expected a SchemaError, got %v", err)
+	}
+	if schemaErr.Path != "/properties/a/properties/b/maximum" {
+		t.Errorf("expected the path of the nested keyword, got %s", schemaErr.Path)
+	}
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	const schema = `{
+		"type": "object",
+		"required": ["lanes"],
+		"additionalProperties": false,
+		"properties": {
+			"lanes": {"type": "integer", "minimum": 1, "maximum": 6},
+			"speed": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 130},
+			"cause": {"type": "string", "enum": ["accident", "works"]},
+			"plate": {"type": "string", "minLength": 2, "maxLength": 4, "pattern": "^[A-Z]+$"},
+			"blocked": {"type": "array", "items": {"type": "integer", "minimum": 0}, "minItems": 1, "maxItems": 2},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"comment": {"type": ["string", "null"]},
+			"open": {"type": "boolean"},
+			"a/b": {"type": "string"}
+		}
+	}`
+	compiled, err := CompileJSONSchema(decodeJSON(t, schema).(map[string]any))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  map[string]string
+	}{
+		{"valid minimal", `{"lanes": 2}`, map[string]string{}},
+		{"valid complete", `{"lanes": 6, "speed": 129.5, "cause": "works", "plate": "AB", "blocked": [0, 3], "labels": {"fr": "travaux"}, "comment": null, "open": true, "a/b": "x"}`, map[string]string{}},
+		{"integer written with a decimal point", `{"lanes": 2.0}`, map[string]string{}},
+		{"length counted in characters", `{"lanes": 1, "plate": "ÉÉÉ"}`, map[string]string{"/plate": "must match pattern ^[A-Z]+$"}},
+		{"root of another type", `[]`, map[string]string{"/": "must be of type object"}},
+		{"missing required property", `{}`, map[string]string{"/lanes": "is required"}},
+		{"additional property", `{"lanes": 1, "other": 1}`, map[string]string{"/other": "is not allowed"}},
+		{"escaped property name", `{"lanes": 1, "a/b": 1}`, map[string]string{"/a~1b": "must be of type string"}},
+		{"fractional integer", `{"lanes": 1.5}`, map[string]string{"/lanes": "must be of type integer"}},
+		{"string instead of integer", `{"lanes": "2"}`, map[string]string{"/lanes": "must be of type integer"}},
+		{"below minimum", `{"lanes": 0}`, map[string]string{"/lanes": "must be greater than or equal to 1"}},
+		{"above maximum", `{"lanes": 7}`, map[string]string{"/lanes": "must be less than or equal to 6"}},
+		{"at exclusive minimum", `{"lanes": 1, "speed": 0}`, map[string]string{"/speed": "must be greater than 0"}},
+		{"at exclusive maximum", `{"lanes": 1, "speed": 130}`, map[string]string{"/speed": "must be less than 130"}},
+		{"value outside enum", `{"lanes": 1, "cause": "fog"}`, map[string]string{"/cause": "must be one of the allowed values"}},
+		{"too short", `{"lanes": 1, "plate": "A"}`, map[string]string{"/plate": "must be at least 2 characters long"}},
+		{"too long", `{"lanes": 1, "plate": "ABCDE"}`, map[string]string{"/plate": "must be at most 4 characters long"}},
+		{"pattern mismatch", `{"lanes": 1, "plate": "ab"}`, map[string]string{"/plate": "must match pattern ^[A-Z]+$"}},
+		{"too few items", `{"lanes": 1, "blocked": []}`, map[string]string{"/blocked": "must contain at least 1 items"}},
+		{"too many items", `{"lanes": 1, "blocked": [1, 2, 3]}`, map[string]string{"/blocked": "must contain at most 2 items"}},
+		{"invalid items", `{"lanes": 1, "blocked": [-1, "a"]}`, map[string]string{"/blocked/0": "must be greater than or equal to 0", "/blocked/1": "must be of type integer"}},
+		{"invalid additional property value", `{"lanes": 1, "labels": {"fr": 1}}`, map[string]string{"/labels/fr": "must be of type string"}},
+		{"type union", `{"lanes": 1, "comment": 1}`, map[string]string{"/comment": "must be of type string or null"}},
+		{"boolean", `{"lanes": 1, "open": "yes"}`, map[string]string{"/open": "must be of type boolean"}},
+		{"several errors", `{"lanes": 9, "cause": "fog", "other": true}`, map[string]string{"/lanes": "must be less than or equal to 6", "/cause": "must be one of the allowed values", "/other": "is not allowed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := compiled.Validate(decodeJSON(t, tt.value))
+			if !maps.Equal(errs, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, errs)
+			}
+		})
+	}
+}
+
+func TestJSONSchemaValidateWithoutConstraints(t *testing.T) {
+	compiled, err := CompileJSONSchema(map[string]any{"type": "object"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Sans properties ni additionalProperties, toutes les propriétés sont acceptées
+	if errs := compiled.Validate(decodeJSON(t, `{"a": 1, "b": {"c": [true]}}`)); len(errs) != 0 {
+		t.Errorf("expected no error, got %v", errs)
+	}
+}
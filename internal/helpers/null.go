@@ -29,3 +29,18 @@ func (ns *NullString) UnmarshalJSON(data []byte) error {
 	ns.Value = &s
 	return nil
 }
+
+// NullObject distingue un objet JSON absent (Set à false) d'un objet explicitement null
+type NullObject struct {
+	Set   bool
+	Value map[string]any
+}
+
+func (no *NullObject) UnmarshalJSON(data []byte) error {
+	no.Set = true
+	if string(data) == "null" {
+		no.Value = nil
+		return nil
+	}
+	return json.Unmarshal(data, &no.Value)
+}
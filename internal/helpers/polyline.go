@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"errors"
+	"math"
+)
+
+// DecodePolyline décode une polyline au format Google (Encoded Polyline Algorithm Format).
+// La précision est de 5 décimales pour Google et de 6 pour certains moteurs de routage (OSRM, Valhalla).
+func DecodePolyline(encoded string, precision int) ([]Point, error) {
+	factor := math.Pow10(precision)
+
+	var points []Point
+	var lat, lon int64
+	index := 0
+
+	for index < len(encoded) {
+		deltaLat, next, err := decodePolylineValue(encoded, index)
+		if err != nil {
+			return nil, err
+		}
+		deltaLon, next, err := decodePolylineValue(encoded, next)
+		if err != nil {
+			return nil, err
+		}
+		index = next
+
+		lat += deltaLat
+		lon += deltaLon
+		points = append(points, Point{
+			Lat: float64(lat) / factor,
+			Lon: float64(lon) / factor,
+		})
+	}
+
+	return points, nil
+}
+
+func decodePolylineValue(encoded string, index int) (int64, int, error) {
+	var result int64
+	var shift uint
+
+	for {
+		if index >= len(encoded) {
+			return 0, index, errors.New("malformed polyline")
+		}
+
+		b := int64(encoded[index]) - 63
+		index++
+		if b < 0 || shift > 60 {
+			return 0, index, errors.New("malformed polyline")
+		}
+
+		result |= (b & 0x1f) << shift
+		shift += 5
+		if b < 0x20 {
+			break
+		}
+	}
+
+	if result&1 != 0 {
+		return ^(result >> 1), index, nil
+	}
+	return result >> 1, index, nil
+}
@@ -0,0 +1,62 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDecodePolyline(t *testing.T) {
+	expected := []Point{
+		{Lat: 38.5, Lon: -120.2},
+		{Lat: 40.7, Lon: -120.95},
+		{Lat: 43.252, Lon: -126.453},
+	}
+
+	tests := []struct {
+		name      string
+		encoded   string
+		precision int
+		expected  []Point
+	}{
+		{name: "precision 5", encoded: "_p~iF~ps|U_ulLnnqC_mqNvxq`@", precision: 5, expected: expected},
+		{name: "precision 6", encoded: "_izlhA~rlgdF_{geC~ywl@_kwzCn`{nI", precision: 6, expected: expected},
+		{name: "empty", encoded: "", precision: 5, expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := DecodePolyline(tt.encoded, tt.precision)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(points) != len(tt.expected) {
+				t.Fatalf("expected %d points, got %d", len(tt.expected), len(points))
+			}
+			for i, p := range points {
+				if math.Abs(p.Lat-tt.expected[i].Lat) > 1e-9 || math.Abs(p.Lon-tt.expected[i].Lon) > 1e-9 {
+					t.Errorf("point %d: expected %v, got %v", i, tt.expected[i], p)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodePolylineMalformed(t *testing.T) {
+	tests := []struct {
+		name    string
+		encoded string
+	}{
+		{name: "truncated value", encoded: "_p~iF~ps|"},
+		{name: "missing longitude", encoded: "_p~iF"},
+		{name: "invalid character", encoded: "_p~iF ps|U"},
+		{name: "overflow", encoded: "~~~~~~~~~~~~~~~"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodePolyline(tt.encoded, 5); err == nil {
+				t.Errorf("expected an error for %q", tt.encoded)
+			}
+		})
+	}
+}
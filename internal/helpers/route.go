@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"math"
+)
+
+// Route est la ligne brisée d'un itinéraire
+type Route []Point
+
+// Project projette un point sur l'itinéraire et retourne la distance en mètres parcourue
+// depuis le début de l'itinéraire jusqu'au projeté, la distance latérale au tracé et le cap
+// en degrés du segment sur lequel le point est projeté, c'est-à-dire le sens de circulation.
+// Chaque segment est traité dans un plan local (projection équirectangulaire),
+// suffisamment précis pour les distances de l'ordre du kilomètre.
+func (r Route) Project(lat, lon float64) (along float64, lateral float64, bearing float64) {
+	lateral = math.Inf(1)
+	cumulated := 0.0
+
+	for i := 0; i < len(r)-1; i++ {
+		a, b := r[i], r[i+1]
+		bx, by := planarOffset(a, b)
+		px, py := planarOffset(a, Point{Lat: lat, Lon: lon})
+
+		segmentLength := math.Hypot(bx, by)
+		t := 0.0
+		if segmentLength > 0 {
+			t = math.Max(0, math.Min(1, (px*bx+py*by)/(segmentLength*segmentLength)))
+		}
+
+		d := math.Hypot(px-t*bx, py-t*by)
+		if d < lateral {
+			lateral = d
+			along = cumulated + t*segmentLength
+			bearing = normalizeHeading(math.Atan2(bx, by) * 180 / math.Pi)
+		}
+
+		cumulated += segmentLength
+	}
+
+	return along, lateral, bearing
+}
+
+// Chunks découpe l'itinéraire en tronçons d'environ maxLength mètres et retourne
+// la zone rectangulaire de chaque tronçon agrandie de margin mètres.
+// Cela évite de requêter la zone englobante complète d'un long itinéraire en diagonale.
+// La zone d'un tronçon qui traverse l'antiméridien est découpée en deux zones.
+func (r Route) Chunks(maxLength, margin float64) []BoundingBox {
+	var boxes []BoundingBox
+	if len(r) == 0 {
+		return boxes
+	}
+
+	// Les longitudes du tronçon sont déroulées depuis son premier point pour qu'un passage
+	// de 179° à -179° ne produise pas une zone couvrant tout le globe
+	lon := r[0].Lon
+	box := BoundingBox{MinLat: r[0].Lat, MinLon: lon, MaxLat: r[0].Lat, MaxLon: lon}
+	length := 0.0
+
+	for i := 1; i < len(r); i++ {
+		lon += wrapLongitudeDelta(r[i].Lon - r[i-1].Lon)
+		box.MinLat = math.Min(box.MinLat, r[i].Lat)
+		box.MinLon = math.Min(box.MinLon, lon)
+		box.MaxLat = math.Max(box.MaxLat, r[i].Lat)
+		box.MaxLon = math.Max(box.MaxLon, lon)
+		length += Distance(r[i-1].Lat, r[i-1].Lon, r[i].Lat, r[i].Lon)
+
+		if length >= maxLength && i < len(r)-1 {
+			boxes = append(boxes, chunkBoxes(box, margin)...)
+			// Le tronçon suivant commence au dernier point du précédent
+			lon = r[i].Lon
+			box = BoundingBox{MinLat: r[i].Lat, MinLon: lon, MaxLat: r[i].Lat, MaxLon: lon}
+			length = 0
+		}
+	}
+
+	return append(boxes, chunkBoxes(box, margin)...)
+}
+
+// chunkBoxes ramène la zone déroulée d'un tronçon dans [-180, 180], l'agrandit et la découpe
+// de part et d'autre de l'antiméridien si besoin
+func chunkBoxes(box BoundingBox, margin float64) []BoundingBox {
+	if box.MaxLon-box.MinLon >= 360 {
+		box.MinLon, box.MaxLon = -180, 180
+	} else {
+		box.MinLon = normalizeLongitude(box.MinLon)
+		box.MaxLon = normalizeLongitude(box.MaxLon)
+	}
+	return ExpandBoundingBox(box, margin).Split()
+}
+
+// wrapLongitudeDelta ramène un écart de longitude dans [-180, 180] en passant par l'antiméridien
+func wrapLongitudeDelta(dLon float64) float64 {
+	if dLon > 180 {
+		dLon -= 360
+	} else if dLon < -180 {
+		dLon += 360
+	}
+	return dLon
+}
+
+func planarOffset(origin, p Point) (x, y float64) {
+	dLon := wrapLongitudeDelta(p.Lon - origin.Lon)
+
+	x = toRadians(dLon) * math.Cos(toRadians(origin.Lat)) * EarthRadius
+	y = toRadians(p.Lat-origin.Lat) * EarthRadius
+	return x, y
+}
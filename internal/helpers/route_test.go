@@ -0,0 +1,155 @@
+package helpers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRouteProject(t *testing.T) {
+	// Un degré de latitude mesure environ 111 195 mètres
+	degree := EarthRadius * math.Pi / 180
+
+	tests := []struct {
+		name            string
+		route           Route
+		lat, lon        float64
+		expectedAlong   float64
+		expectedLateral float64
+		expectedBearing float64
+	}{
+		{
+			name:            "point on a northbound segment",
+			route:           Route{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}},
+			lat:             0.5,
+			lon:             0,
+			expectedAlong:   degree / 2,
+			expectedLateral: 0,
+			expectedBearing: 0,
+		},
+		{
+			name:            "point beside an eastbound segment",
+			route:           Route{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 1}},
+			lat:             0.01,
+			lon:             0.5,
+			expectedAlong:   degree / 2,
+			expectedLateral: degree / 100,
+			expectedBearing: 90,
+		},
+		{
+			name:            "point before the start of the route",
+			route:           Route{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}},
+			lat:             -0.5,
+			lon:             0,
+			expectedAlong:   0,
+			expectedLateral: degree / 2,
+			expectedBearing: 0,
+		},
+		{
+			name:            "second segment of a southbound route",
+			route:           Route{{Lat: 2, Lon: 0}, {Lat: 1, Lon: 0}, {Lat: 0, Lon: 0}},
+			lat:             0.5,
+			lon:             0,
+			expectedAlong:   degree * 1.5,
+			expectedLateral: 0,
+			expectedBearing: 180,
+		},
+		{
+			name:            "westbound segment across the antimeridian",
+			route:           Route{{Lat: 0, Lon: -179.5}, {Lat: 0, Lon: 179.5}},
+			lat:             0,
+			lon:             180,
+			expectedAlong:   degree / 2,
+			expectedLateral: 0,
+			expectedBearing: 270,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			along, lateral, bearing := tt.route.Project(tt.lat, tt.lon)
+			if math.Abs(along-tt.expectedAlong) > 1 {
+				t.Errorf("along: expected %f, got %f", tt.expectedAlong, along)
+			}
+			if math.Abs(lateral-tt.expectedLateral) > 1 {
+				t.Errorf("lateral: expected %f, got %f", tt.expectedLateral, lateral)
+			}
+			if HeadingDifference(bearing, tt.expectedBearing) > 0.01 {
+				t.Errorf("bearing: expected %f, got %f", tt.expectedBearing, bearing)
+			}
+		})
+	}
+}
+
+func TestRouteChunks(t *testing.T) {
+	t.Run("empty route", func(t *testing.T) {
+		if boxes := (Route{}).Chunks(1000, 100); len(boxes) != 0 {
+			t.Errorf("expected no box, got %v", boxes)
+		}
+	})
+
+	t.Run("short route is a single box", func(t *testing.T) {
+		route := Route{{Lat: 48.85, Lon: 2.35}, {Lat: 48.86, Lon: 2.36}}
+		boxes := route.Chunks(10000, 0)
+		if len(boxes) != 1 {
+			t.Fatalf("expected 1 box, got %d", len(boxes))
+		}
+		expected := BoundingBox{MinLat: 48.85, MinLon: 2.35, MaxLat: 48.86, MaxLon: 2.36}
+		if boxes[0] != expected {
+			t.Errorf("expected %v, got %v", expected, boxes[0])
+		}
+	})
+
+	t.Run("long route is split into consecutive chunks", func(t *testing.T) {
+		// Environ 11 km entre chaque point
+		route := Route{{Lat: 0, Lon: 0}, {Lat: 0.1, Lon: 0}, {Lat: 0.2, Lon: 0}, {Lat: 0.3, Lon: 0}, {Lat: 0.4, Lon: 0}}
+		boxes := route.Chunks(20000, 0)
+		if len(boxes) != 2 {
+			t.Fatalf("expected 2 boxes, got %d: %v", len(boxes), boxes)
+		}
+		if boxes[0].MinLat != 0 || boxes[0].MaxLat != 0.2 {
+			t.Errorf("unexpected first chunk %v", boxes[0])
+		}
+		// Le second tronçon commence au dernier point du premier
+		if boxes[1].MinLat != 0.2 || boxes[1].MaxLat != 0.4 {
+			t.Errorf("unexpected second chunk %v", boxes[1])
+		}
+	})
+
+	t.Run("margin expands the box", func(t *testing.T) {
+		route := Route{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 0.01}}
+		boxes := route.Chunks(10000, 1000)
+		if len(boxes) != 1 {
+			t.Fatalf("expected 1 box, got %d", len(boxes))
+		}
+		for _, p := range []Point{{Lat: 0.008, Lon: 0.005}, {Lat: -0.008, Lon: -0.008}, {Lat: 0, Lon: 0.018}} {
+			if !boxes[0].Contains(p.Lat, p.Lon) {
+				t.Errorf("expected %v to be contained in %v", p, boxes[0])
+			}
+		}
+		if boxes[0].Contains(0.01, 0) {
+			t.Errorf("expected %v to exclude points beyond the margin", boxes[0])
+		}
+	})
+
+	t.Run("chunk crossing the antimeridian is split in two", func(t *testing.T) {
+		route := Route{{Lat: 10, Lon: 179.9}, {Lat: 10.1, Lon: -179.9}}
+		boxes := route.Chunks(100000, 0)
+		expected := []BoundingBox{
+			{MinLat: 10, MinLon: 179.9, MaxLat: 10.1, MaxLon: 180},
+			{MinLat: 10, MinLon: -180, MaxLat: 10.1, MaxLon: -179.9},
+		}
+		if len(boxes) != len(expected) {
+			t.Fatalf("expected %d boxes, got %d: %v", len(expected), len(boxes), boxes)
+		}
+		for i := range expected {
+			if !sameBox(boxes[i], expected[i]) {
+				t.Errorf("box %d: expected %v, got %v", i, expected[i], boxes[i])
+			}
+		}
+	})
+}
+
+func sameBox(a, b BoundingBox) bool {
+	return math.Abs(a.MinLat-b.MinLat) < 1e-9 && math.Abs(a.MinLon-b.MinLon) < 1e-9 &&
+		math.Abs(a.MaxLat-b.MaxLat) < 1e-9 && math.Abs(a.MaxLon-b.MaxLon) < 1e-9
+}
@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"errors"
+	"time"
+)
+
+// SyncToken identifie la dernière modification d'incident connue d'un client, dans la
+// liste des modifications triée par date de mise à jour puis par ID. Il est transmis
+// aux clients sous forme opaque.
+type SyncToken struct {
+	UpdatedAt time.Time
+	ID        int64
+}
+
+var ErrInvalidSyncToken = errors.New("invalid sync token")
+
+func (t *SyncToken) Encode() string {
+	return (&Cursor{CreatedAt: t.UpdatedAt, ID: t.ID}).Encode()
+}
+
+func DecodeSyncToken(encoded string) (*SyncToken, error) {
+	cursor, err := DecodeCursor(encoded)
+	if err != nil {
+		return nil, ErrInvalidSyncToken
+	}
+	return &SyncToken{UpdatedAt: cursor.CreatedAt, ID: cursor.ID}, nil
+}
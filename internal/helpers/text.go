@@ -0,0 +1,110 @@
+package helpers
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// SanitizeText nettoie un texte saisi par un utilisateur : les balises HTML, les caractères
+// de contrôle et les caractères invisibles sont retirés, les espaces consécutifs sont réduits
+// à un seul et le texte est rogné
+func SanitizeText(text string) string {
+	text = strings.ToValidUTF8(text, "")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case r == '<' || r == '>' || unicode.IsControl(r) || unicode.Is(unicode.Cf, r):
+			return -1
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// ProfanityFilter masque les mots interdits d'un texte. La comparaison ignore la casse
+// et porte sur des mots entiers, "con" ne masque donc pas "conducteur".
+type ProfanityFilter struct {
+	words map[string]struct{}
+}
+
+// NewProfanityFilter crée un filtre à partir de la liste de mots et des mots du fichier, un par ligne.
+// Les lignes vides et celles commençant par # sont ignorées.
+func NewProfanityFilter(words []string, file string) (*ProfanityFilter, error) {
+	filter := &ProfanityFilter{words: make(map[string]struct{})}
+	for _, word := range words {
+		filter.add(word)
+	}
+
+	if file == "" {
+		return filter, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open profanity word list: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); !strings.HasPrefix(line, "#") {
+			filter.add(line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read profanity word list: %w", err)
+	}
+
+	return filter, nil
+}
+
+func (f *ProfanityFilter) add(word string) {
+	if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+		f.words[word] = struct{}{}
+	}
+}
+
+// Filter remplace chaque lettre des mots interdits par un astérisque
+func (f *ProfanityFilter) Filter(text string) string {
+	if len(f.words) == 0 {
+		return text
+	}
+
+	var builder strings.Builder
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := text[start:end]
+		if _, ok := f.words[strings.ToLower(word)]; ok {
+			builder.WriteString(strings.Repeat("*", utf8.RuneCountInString(word)))
+		} else {
+			builder.WriteString(word)
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		builder.WriteRune(r)
+	}
+	flush(len(text))
+
+	return builder.String()
+}
@@ -0,0 +1,109 @@
+package helpers
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSanitizeText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain text", "Bouchon sur l'A6", "Bouchon sur l'A6"},
+		{"accents kept", "Chaussée glissante à Évry", "Chaussée glissante à Évry"},
+		{"empty", "", ""},
+		{"spaces collapsed and trimmed", "  Bouchon   sur  l'A6 ", "Bouchon sur l'A6"},
+		{"html tags", "<b>Accident</b> voie <i>droite</i>", "Accident voie droite"},
+		{"tag between words", "Accident<br>voie<br/>droite", "Accident voie droite"},
+		{"tag with attributes", `<a href="https://example.com" onclick="alert(1)">lien</a>`, "lien"},
+		{"script tag content kept as text", "<script>alert(1)</script>Travaux", "alert(1) Travaux"},
+		{"only tags", "<br><hr/>", ""},
+		{"unclosed angle bracket", "3 < 5", "3 5"},
+		{"closing angle bracket", "voie -> droite", "voie - droite"},
+		{"line breaks and tabs", "Voie\n\tbloquée\r\n", "Voie bloquée"},
+		{"non-breaking space", "Voie\u00a0bloquée", "Voie bloquée"},
+		{"control characters", "Voie\x00 bloquée\x07\x1b", "Voie bloquée"},
+		{"zero width characters", "Ac\u200bci\u200ddent\ufeff", "Accident"},
+		{"bidirectional override", "\u202eeiov\u202c bloquée", "eiov bloquée"},
+		{"soft hyphen", "bou\u00adchon", "bouchon"},
+		{"invalid utf-8", "Voie\xff bloquée", "Voie bloquée"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SanitizeText(tt.text); got != tt.want {
+				t.Errorf("SanitizeText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfanityFilter(t *testing.T) {
+	filter, err := NewProfanityFilter([]string{"con", " Merde ", "ÉPAVE", ""}, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"whole word", "con", "***"},
+		{"case insensitive", "Con", "***"},
+		{"upper case with punctuation", "CON!", "***!"},
+		{"longer word kept", "conducteur", "conducteur"},
+		{"word inside a sentence", "le con du conducteur", "le *** du conducteur"},
+		{"word added with spaces and capitals", "merde alors", "***** alors"},
+		{"accented variant kept", "merdé", "merdé"},
+		{"word followed by a digit kept", "con2", "con2"},
+		{"apostrophe", "l'con", "l'***"},
+		{"hyphen", "con-con", "***-***"},
+		{"non-ASCII word masked per character", "une épave", "une *****"},
+		{"non-ASCII word with another case", "Épave", "*****"},
+		{"no forbidden word", "Accident sur la voie de droite", "Accident sur la voie de droite"},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := filter.Filter(tt.text); got != tt.want {
+				t.Errorf("Filter(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProfanityFilterWithoutWords(t *testing.T) {
+	filter, err := NewProfanityFilter(nil, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := filter.Filter("con"); got != "con" {
+		t.Errorf("expected text unchanged, got %q", got)
+	}
+}
+
+func TestNewProfanityFilterFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "words.txt")
+	content := "# Liste de test\n\ncon\n  Merde  \n#ignored\n"
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	filter, err := NewProfanityFilter([]string{"zut"}, file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := filter.Filter("zut, con, merde, ignored, # Liste"); got != "***, ***, *****, ignored, # Liste" {
+		t.Errorf("unexpected filtered text %q", got)
+	}
+
+	if _, err := NewProfanityFilter(nil, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
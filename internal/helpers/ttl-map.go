@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"container/list"
+	"time"
+)
+
+// ttlMapPurgeThreshold est la taille au-delà de laquelle les entrées expirées sont purgées lors d'un ajout
+const ttlMapPurgeThreshold = 10000
+
+type ttlEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+// TTLMap est une map dont les entrées expirent après une durée de vie. Les entrées expirées ne sont
+// plus retournées et sont purgées lors d'un ajout lorsque la map dépasse 10 000 entrées.
+// Avec une capacité, la map est bornée : l'entrée la moins récemment utilisée est retirée
+// lorsqu'un ajout dépasse la capacité.
+// TTLMap n'est pas protégée contre les accès concurrents : son propriétaire la protège par son propre verrou.
+type TTLMap[K comparable, V any] struct {
+	capacity int
+	entries  map[K]*list.Element
+	// recency ordonne les entrées de la plus récemment utilisée à la moins récemment utilisée
+	recency *list.List
+	// onRemove est appelée pour chaque entrée retirée par la purge ou pour respecter la capacité
+	onRemove func(key K, value V)
+}
+
+// NewTTLMap crée une map vide, de taille illimitée si capacity vaut 0. onRemove est optionnelle.
+func NewTTLMap[K comparable, V any](capacity int, onRemove func(key K, value V)) *TTLMap[K, V] {
+	return &TTLMap[K, V]{
+		capacity: capacity,
+		entries:  make(map[K]*list.Element),
+		recency:  list.New(),
+		onRemove: onRemove,
+	}
+}
+
+// Get retourne la valeur associée à la clé si elle n'a pas expiré
+func (m *TTLMap[K, V]) Get(key K) (V, bool) {
+	element, ok := m.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	entry := element.Value.(*ttlEntry[K, V])
+	if !time.Now().Before(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+
+	m.recency.MoveToFront(element)
+	return entry.value, true
+}
+
+// Set associe la valeur à la clé pendant ttl
+func (m *TTLMap[K, V]) Set(key K, value V, ttl time.Duration) {
+	now := time.Now()
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*ttlEntry[K, V])
+		entry.value, entry.expiresAt = value, now.Add(ttl)
+		m.recency.MoveToFront(element)
+		return
+	}
+
+	if len(m.entries) > ttlMapPurgeThreshold {
+		m.purge(now)
+	}
+
+	m.entries[key] = m.recency.PushFront(&ttlEntry[K, V]{key: key, value: value, expiresAt: now.Add(ttl)})
+	if m.capacity > 0 && len(m.entries) > m.capacity {
+		m.remove(m.recency.Back())
+	}
+}
+
+func (m *TTLMap[K, V]) Delete(key K) {
+	if element, ok := m.entries[key]; ok {
+		delete(m.entries, key)
+		m.recency.Remove(element)
+	}
+}
+
+// Clear retire toutes les entrées, sans appeler onRemove
+func (m *TTLMap[K, V]) Clear() {
+	clear(m.entries)
+	m.recency.Init()
+}
+
+func (m *TTLMap[K, V]) purge(now time.Time) {
+	for _, element := range m.entries {
+		if now.After(element.Value.(*ttlEntry[K, V]).expiresAt) {
+			m.remove(element)
+		}
+	}
+}
+
+func (m *TTLMap[K, V]) remove(element *list.Element) {
+	entry := element.Value.(*ttlEntry[K, V])
+	delete(m.entries, entry.key)
+	m.recency.Remove(element)
+	if m.onRemove != nil {
+		m.onRemove(entry.key, entry.value)
+	}
+}
@@ -0,0 +1,102 @@
+package helpers
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestTTLMap(t *testing.T) {
+	m := NewTTLMap[string, int](0, nil)
+
+	m.Set("a", 1, time.Minute)
+	if value, ok := m.Get("a"); !ok || value != 1 {
+		t.Errorf("expected 1, got %d (found: %t)", value, ok)
+	}
+
+	m.Set("expired", 2, -time.Second)
+	if _, ok := m.Get("expired"); ok {
+		t.Error("expected expired entry to be ignored")
+	}
+
+	m.Delete("a")
+	if _, ok := m.Get("a"); ok {
+		t.Error("expected deleted entry to be absent")
+	}
+
+	m.Set("b", 3, time.Minute)
+	m.Clear()
+	if _, ok := m.Get("b"); ok {
+		t.Error("expected cleared map to be empty")
+	}
+}
+
+func TestTTLMapPurge(t *testing.T) {
+	purged := make(map[string]int)
+	m := NewTTLMap(0, func(key string, value int) {
+		purged[key] = value
+	})
+
+	for i := 0; i <= ttlMapPurgeThreshold; i++ {
+		ttl := time.Minute
+		if i%2 == 0 {
+			ttl = -time.Second
+		}
+		m.Set(strconv.Itoa(i), i, ttl)
+	}
+	if len(purged) != 0 {
+		t.Fatalf("expected no purge below the threshold, got %d purged entries", len(purged))
+	}
+
+	// L'ajout suivant dépasse le seuil et purge les entrées expirées
+	m.Set("last", -1, time.Minute)
+	if len(purged) != ttlMapPurgeThreshold/2+1 {
+		t.Errorf("expected %d purged entries, got %d", ttlMapPurgeThreshold/2+1, len(purged))
+	}
+	if len(m.entries) != ttlMapPurgeThreshold/2+1 {
+		t.Errorf("expected %d remaining entries, got %d", ttlMapPurgeThreshold/2+1, len(m.entries))
+	}
+	if _, ok := purged["0"]; !ok {
+		t.Error("expected expired entry 0 to be passed to onPurge")
+	}
+	if _, ok := m.Get("1"); !ok {
+		t.Error("expected live entry 1 to be kept")
+	}
+}
+
+func TestTTLMapCapacity(t *testing.T) {
+	var evicted []string
+	m := NewTTLMap(2, func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	m.Set("a", 1, time.Minute)
+	m.Set("b", 2, time.Minute)
+	// La lecture de "a" en fait l'entrée la plus récemment utilisée
+	m.Get("a")
+	m.Set("c", 3, time.Minute)
+
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Fatalf("expected b to be evicted, got %v", evicted)
+	}
+	if _, ok := m.Get("a"); !ok {
+		t.Error("expected recently used entry a to be kept")
+	}
+	if _, ok := m.Get("c"); !ok {
+		t.Error("expected new entry c to be kept")
+	}
+
+	// La mise à jour d'une clé existante ne retire aucune entrée
+	m.Set("a", 4, time.Minute)
+	if len(evicted) != 1 || len(m.entries) != 2 || m.recency.Len() != 2 {
+		t.Errorf("expected update to keep 2 entries, got %d (evicted: %v)", len(m.entries), evicted)
+	}
+	if value, _ := m.Get("a"); value != 4 {
+		t.Errorf("expected updated value 4, got %d", value)
+	}
+
+	m.Set("d", 5, time.Minute)
+	if evicted[len(evicted)-1] != "c" {
+		t.Errorf("expected c to be evicted, got %v", evicted)
+	}
+}
@@ -0,0 +1,32 @@
+package models
+
+import "supmap-users/internal/helpers"
+
+// IncidentCell compte les incidents actifs d'un type dans une cellule geohash
+type IncidentCell struct {
+	Geohash string  `bun:"geohash"`
+	TypeID  int64   `bun:"type_id"`
+	Count   int     `bun:"count"`
+	SumLat  float64 `bun:"sum_lat"`
+	SumLon  float64 `bun:"sum_lon"`
+	MinLat  float64 `bun:"min_lat"`
+	MinLon  float64 `bun:"min_lon"`
+	MaxLat  float64 `bun:"max_lat"`
+	MaxLon  float64 `bun:"max_lon"`
+}
+
+// Cluster regroupe les incidents actifs d'une cellule geohash, tous types confondus
+type Cluster struct {
+	Geohash   string
+	Latitude  float64
+	Longitude float64
+	Count     int
+	Bounds    helpers.BoundingBox
+	Types     []TypeCount
+}
+
+// TypeCount est le nombre d'incidents d'un type dans un cluster
+type TypeCount struct {
+	Type  *Type
+	Count int
+}
@@ -0,0 +1,57 @@
+package dto
+
+import "supmap-users/internal/models"
+
+type BoundsDTO struct {
+	MinLat float64 `json:"min_lat"`
+	MinLon float64 `json:"min_lon"`
+	MaxLat float64 `json:"max_lat"`
+	MaxLon float64 `json:"max_lon"`
+}
+
+type TypeCountDTO struct {
+	Type  *TypeDTO `json:"type"`
+	Count int      `json:"count"`
+}
+
+type ClusterDTO struct {
+	Geohash   string         `json:"geohash"`
+	Latitude  float64        `json:"lat"`
+	Longitude float64        `json:"lon"`
+	Count     int            `json:"count"`
+	Bounds    BoundsDTO      `json:"bounds"`
+	Types     []TypeCountDTO `json:"types"`
+}
+
+// ClustersResultDTO contient les clusters de la zone, ou ses incidents lorsque le zoom est suffisant
+type ClustersResultDTO struct {
+	Zoom      int           `json:"zoom"`
+	Clusters  []ClusterDTO  `json:"clusters"`
+	Incidents []IncidentDTO `json:"incidents,omitempty"`
+	Truncated bool          `json:"truncated"`
+}
+
+func ClusterToDTO(cluster *models.Cluster) *ClusterDTO {
+	clusterDTO := ClusterDTO{
+		Geohash:   cluster.Geohash,
+		Latitude:  cluster.Latitude,
+		Longitude: cluster.Longitude,
+		Count:     cluster.Count,
+		Bounds: BoundsDTO{
+			MinLat: cluster.Bounds.MinLat,
+			MinLon: cluster.Bounds.MinLon,
+			MaxLat: cluster.Bounds.MaxLat,
+			MaxLon: cluster.Bounds.MaxLon,
+		},
+		Types: make([]TypeCountDTO, len(cluster.Types)),
+	}
+
+	for i, count := range cluster.Types {
+		clusterDTO.Types[i].Count = count.Count
+		if count.Type != nil {
+			clusterDTO.Types[i].Type = TypeToDTO(count.Type)
+		}
+	}
+
+	return &clusterDTO
+}
@@ -6,4 +6,12 @@ const (
 	Ignore              = "ignore"
 	IncludeInteractions = "include_interactions"
 	IncludeAsSummary    = "include_as_summary"
-)
\ No newline at end of file
+)
+
+type IncidentStatus string
+
+const (
+	StatusActive    IncidentStatus = "active"
+	StatusCertified IncidentStatus = "certified"
+	StatusExpired   IncidentStatus = "expired"
+)
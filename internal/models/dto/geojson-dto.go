@@ -0,0 +1,55 @@
+package dto
+
+type PointGeometryDTO struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+type FeatureDTO[T any] struct {
+	Type       string           `json:"type"`
+	ID         int64            `json:"id"`
+	Geometry   PointGeometryDTO `json:"geometry"`
+	Properties T                `json:"properties"`
+}
+
+type FeatureCollectionDTO[T any] struct {
+	Type     string          `json:"type"`
+	Features []FeatureDTO[T] `json:"features"`
+
+	// Membres étrangers repris des réponses JSON équivalentes
+	Truncated         *bool   `json:"truncated,omitempty"`
+	NeedRecalculation *bool   `json:"need_recalculation,omitempty"`
+	NextCursor        *string `json:"next_cursor,omitempty"`
+	Total             *int    `json:"total,omitempty"`
+}
+
+type geoLocated interface {
+	location() (id int64, lat float64, lon float64)
+}
+
+func (i IncidentDTO) location() (int64, float64, float64) {
+	return i.ID, i.Latitude, i.Longitude
+}
+
+// ToFeatureCollection convertit une liste de DTOs en FeatureCollection GeoJSON (RFC 7946).
+// Chaque DTO est repris tel quel dans les propriétés de sa Feature.
+func ToFeatureCollection[T geoLocated](items []T) *FeatureCollectionDTO[T] {
+	features := make([]FeatureDTO[T], len(items))
+	for i, item := range items {
+		id, lat, lon := item.location()
+		features[i] = FeatureDTO[T]{
+			Type: "Feature",
+			ID:   id,
+			Geometry: PointGeometryDTO{
+				Type:        "Point",
+				Coordinates: [2]float64{lon, lat}, // GeoJSON impose l'ordre longitude, latitude
+			},
+			Properties: item,
+		}
+	}
+
+	return &FeatureCollectionDTO[T]{
+		Type:     "FeatureCollection",
+		Features: features,
+	}
+}
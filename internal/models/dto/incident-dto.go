@@ -6,40 +6,60 @@ import (
 )
 
 type IncidentDTO struct {
-	ID        int64           `json:"id"`
-	User      *PartialUserDTO `json:"user"`
-	Type      *TypeDTO        `json:"type"`
-	Latitude  float64         `json:"lat"`
-	Longitude float64         `json:"lon"`
-	CreatedAt time.Time       `json:"created_at"`
-	UpdatedAt time.Time       `json:"updated_at"`
-	DeletedAt *time.Time      `json:"deleted_at,omitempty"`
+	ID             int64           `json:"id"`
+	User           *PartialUserDTO `json:"user"`
+	Type           *TypeDTO        `json:"type"`
+	Latitude       float64         `json:"lat"`
+	Longitude      float64         `json:"lon"`
+	Geohash        string          `json:"geohash"`
+	Heading        *float64        `json:"heading,omitempty"`
+	BothDirections bool            `json:"both_directions"`
+	Severity       string          `json:"severity"`
+	Description    *string         `json:"description,omitempty"`
+	Attributes     map[string]any  `json:"attributes,omitempty"`
+	Photos         []PhotoDTO      `json:"photos,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	DeletedAt      *time.Time      `json:"deleted_at,omitempty"`
+	Status         IncidentStatus  `json:"status"`
 
 	Interactions        []InteractionDTO        `json:"interactions,omitempty"`
 	InteractionsSummary *InteractionsSummaryDTO `json:"interactions_summary,omitempty"`
 }
 
+type BoundingBoxResultDTO struct {
+	Incidents []IncidentDTO `json:"incidents"`
+	Truncated bool          `json:"truncated"`
+}
+
 type IncidentWithDistanceDTO struct {
 	IncidentDTO
 	Distance float64 `json:"distance"`
 }
 
-func IncidentToDTO(incident *models.Incident, interactionsState InteractionsResultState) *IncidentDTO {
-	partialUserDTO, _ := UserIdToDTO(incident.UserID)
+func IncidentToDTO(incident *models.Incident, interactionsState InteractionsResultState, users Users) *IncidentDTO {
 	incidentDTO := IncidentDTO{
-		ID:        incident.ID,
-		User:      partialUserDTO,
-		Type:      TypeToDTO(incident.Type),
-		Latitude:  incident.Latitude,
-		Longitude: incident.Longitude,
-		CreatedAt: incident.CreatedAt,
-		UpdatedAt: incident.UpdatedAt,
-		DeletedAt: incident.DeletedAt,
+		ID:             incident.ID,
+		User:           users.Get(incident.UserID),
+		Type:           TypeToDTO(incident.Type),
+		Latitude:       incident.Latitude,
+		Longitude:      incident.Longitude,
+		Geohash:        incident.Geohash,
+		Heading:        incident.Heading,
+		BothDirections: incident.BothDirections,
+		Severity:       incident.Severity,
+		Description:    incident.Description,
+		Attributes:     incident.Attributes,
+		Photos:         buildPhotosDTO(incident.Photos),
+		CreatedAt:      incident.CreatedAt,
+		UpdatedAt:      incident.UpdatedAt,
+		DeletedAt:      incident.DeletedAt,
+		Status:         IncidentToStatus(incident),
 	}
 
 	switch interactionsState {
 	case IncludeInteractions:
-		incidentDTO.Interactions = buildInteractionsDTO(incident.Interactions)
+		incidentDTO.Interactions = buildInteractionsDTO(incident.Interactions, users)
 	case IncludeAsSummary:
 		incidentDTO.InteractionsSummary = InteractionsToSummaryDTO(incident.Interactions)
 	case Ignore:
@@ -50,26 +70,26 @@ func IncidentToDTO(incident *models.Incident, interactionsState InteractionsResu
 	return &incidentDTO
 }
 
-func buildInteractionsDTO(interactions []models.Interaction) []InteractionDTO {
+func IncidentToStatus(incident *models.Incident) IncidentStatus {
+	if !incident.IsActive() {
+		return StatusExpired
+	}
+	if incident.IsCertified() {
+		return StatusCertified
+	}
+	return StatusActive
+}
+
+func buildInteractionsDTO(interactions []models.Interaction, users Users) []InteractionDTO {
 	var interactionsDTO = make([]InteractionDTO, len(interactions))
 	for i, dto := range interactions {
-		interactionsDTO[i] = *InteractionToDTO(dto, Ignore)
+		interactionsDTO[i] = *InteractionToDTO(dto, Ignore, users)
 	}
 	return interactionsDTO
 }
 
-func IncidentWithDistanceToDTO(incident *models.IncidentWithDistance, interactionsState InteractionsResultState) *IncidentWithDistanceDTO {
-	incidentDTO := *IncidentToDTO(&models.Incident{
-		ID:           incident.ID,
-		UserID:       incident.UserID,
-		Type:         incident.Type,
-		Latitude:     incident.Latitude,
-		Longitude:    incident.Longitude,
-		CreatedAt:    incident.CreatedAt,
-		UpdatedAt:    incident.UpdatedAt,
-		DeletedAt:    incident.DeletedAt,
-		Interactions: incident.Interactions,
-	}, interactionsState)
+func IncidentWithDistanceToDTO(incident *models.IncidentWithDistance, interactionsState InteractionsResultState, users Users) *IncidentWithDistanceDTO {
+	incidentDTO := *IncidentToDTO(&incident.Incident, interactionsState, users)
 
 	return &IncidentWithDistanceDTO{
 		IncidentDTO: incidentDTO,
@@ -77,26 +97,70 @@ func IncidentWithDistanceToDTO(incident *models.IncidentWithDistance, interactio
 	}
 }
 
+type IncidentOnRouteDTO struct {
+	IncidentDTO
+	AlongRouteDistance float64 `json:"along_route_distance"`
+	LateralDistance    float64 `json:"lateral_distance"`
+	NeedRecalculation  bool    `json:"need_recalculation"`
+}
+
+type RouteIncidentsDTO struct {
+	Incidents         []IncidentOnRouteDTO `json:"incidents"`
+	NeedRecalculation bool                 `json:"need_recalculation"`
+}
+
+func IncidentOnRouteToDTO(incident *models.IncidentOnRoute, interactionsState InteractionsResultState, users Users) *IncidentOnRouteDTO {
+	return &IncidentOnRouteDTO{
+		IncidentDTO:        *IncidentToDTO(&incident.Incident, interactionsState, users),
+		AlongRouteDistance: incident.AlongRouteDistance,
+		LateralDistance:    incident.LateralDistance,
+		// Un incident bloquant impose de recalculer l'itinéraire quel que soit son type
+		NeedRecalculation: (incident.Type != nil && incident.Type.NeedRecalculation) || incident.Severity == models.SeverityBlocking,
+	}
+}
+
 type IncidentRedis struct {
-	ID        int64      `json:"id"`
-	UserId    int64      `json:"user_id"`
-	Type      *TypeDTO   `json:"type"`
-	Latitude  float64    `json:"lat"`
-	Longitude float64    `json:"lon"`
-	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	ID             int64          `json:"id"`
+	UserId         int64          `json:"user_id"`
+	Type           *TypeDTO       `json:"type"`
+	Latitude       float64        `json:"lat"`
+	Longitude      float64        `json:"lon"`
+	Geohash        string         `json:"geohash"`
+	Heading        *float64       `json:"heading,omitempty"`
+	BothDirections bool           `json:"both_directions"`
+	Severity       string         `json:"severity"`
+	Description    *string        `json:"description,omitempty"`
+	Attributes     map[string]any `json:"attributes,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty"`
 }
 
 func IncidentToRedis(incident *models.Incident) *IncidentRedis {
 	return &IncidentRedis{
-		ID:        incident.ID,
-		UserId:    incident.UserID,
-		Type:      TypeToDTO(incident.Type),
-		Latitude:  incident.Latitude,
-		Longitude: incident.Longitude,
-		CreatedAt: incident.CreatedAt,
-		UpdatedAt: incident.UpdatedAt,
-		DeletedAt: incident.DeletedAt,
+		ID:             incident.ID,
+		UserId:         incident.UserID,
+		Type:           TypeToDTO(incident.Type),
+		Latitude:       incident.Latitude,
+		Longitude:      incident.Longitude,
+		Geohash:        incident.Geohash,
+		Heading:        incident.Heading,
+		BothDirections: incident.BothDirections,
+		Severity:       incident.Severity,
+		Description:    incident.Description,
+		Attributes:     incident.Attributes,
+		CreatedAt:      incident.CreatedAt,
+		UpdatedAt:      incident.UpdatedAt,
+		DeletedAt:      incident.DeletedAt,
 	}
 }
+
+// IncidentChangesDTO liste les incidents d'une zone modifiés depuis un jeton de synchronisation.
+// Les incidents expirés ne sont identifiés que par leur ID, le client n'a qu'à les retirer.
+type IncidentChangesDTO struct {
+	Created   []IncidentDTO `json:"created"`
+	Updated   []IncidentDTO `json:"updated"`
+	Expired   []int64       `json:"expired"`
+	SyncToken string        `json:"sync_token"`
+	HasMore   bool          `json:"has_more"`
+}
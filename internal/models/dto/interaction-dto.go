@@ -9,22 +9,23 @@ type InteractionDTO struct {
 	ID             int64           `json:"id"`
 	User           *PartialUserDTO `json:"user"`
 	IsStillPresent bool            `json:"is_still_present"`
+	SeverityChange *string         `json:"severity_change,omitempty"`
 	CreatedAt      time.Time       `json:"created_at"`
 
 	Incident *IncidentDTO `json:"incident,omitempty"`
 }
 
-func InteractionToDTO(interaction models.Interaction, includeIncidents InteractionsResultState) *InteractionDTO {
-	partialUserDTO, _ := UserIdToDTO(interaction.UserID)
+func InteractionToDTO(interaction models.Interaction, includeIncidents InteractionsResultState, users Users) *InteractionDTO {
 	interactionDTO := InteractionDTO{
 		ID:             interaction.ID,
-		User:           partialUserDTO,
+		User:           users.Get(interaction.UserID),
 		IsStillPresent: interaction.IsStillPresent,
+		SeverityChange: interaction.SeverityChange,
 		CreatedAt:      interaction.CreatedAt,
 	}
 
 	if includeIncidents != Ignore {
-		interactionDTO.Incident = IncidentToDTO(interaction.Incident, includeIncidents)
+		interactionDTO.Incident = IncidentToDTO(interaction.Incident, includeIncidents, users)
 	}
 
 	return &interactionDTO
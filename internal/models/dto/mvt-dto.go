@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/encoding/mvt"
+	"github.com/paulmach/orb/geojson"
+	"github.com/paulmach/orb/maptile"
+	"supmap-users/internal/models"
+	"time"
+)
+
+// IncidentsLayer est le nom de la couche des tuiles vectorielles contenant les incidents
+const IncidentsLayer = "incidents"
+
+// IncidentsToVectorTile encode les incidents dans la couche incidents d'une tuile Mapbox Vector Tile.
+// L'âge des incidents est calculé au moment de l'encodage.
+func IncidentsToVectorTile(incidents []models.Incident, tile maptile.Tile) ([]byte, error) {
+	now := time.Now()
+	collection := geojson.NewFeatureCollection()
+
+	for _, incident := range incidents {
+		feature := geojson.NewFeature(orb.Point{incident.Longitude, incident.Latitude})
+		feature.ID = incident.ID
+
+		summary := InteractionsToSummaryDTO(incident.Interactions)
+		feature.Properties = geojson.Properties{
+			"id":               incident.ID,
+			"created_at":       incident.CreatedAt.Unix(),
+			"age":              int64(now.Sub(incident.CreatedAt).Seconds()),
+			"certified":        incident.IsCertified(),
+			"is_still_present": summary.IsStillPresentSum,
+			"no_still_present": summary.NoStillPresentSum,
+			"total":            summary.Total,
+			"both_directions":  incident.BothDirections,
+			"severity":         incident.Severity,
+		}
+		if incident.Heading != nil {
+			feature.Properties["heading"] = *incident.Heading
+		}
+		if incident.Type != nil {
+			feature.Properties["type_id"] = incident.Type.ID
+			feature.Properties["type_name"] = incident.Type.Name
+		}
+
+		collection.Append(feature)
+	}
+
+	layers := mvt.NewLayers(map[string]*geojson.FeatureCollection{IncidentsLayer: collection})
+	layers.ProjectToTile(tile)
+	return mvt.Marshal(layers)
+}
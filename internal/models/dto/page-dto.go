@@ -0,0 +1,7 @@
+package dto
+
+type PageDTO[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+	Total      int     `json:"total"`
+}
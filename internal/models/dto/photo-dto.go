@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"supmap-users/internal/config"
+	"supmap-users/internal/models"
+	"time"
+)
+
+type PhotoDTO struct {
+	ID            int64     `json:"id"`
+	URL           string    `json:"url"`
+	ThumbnailURL  string    `json:"thumbnail_url"`
+	Width         int       `json:"width"`
+	Height        int       `json:"height"`
+	InteractionID *int64    `json:"interaction_id,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+func PhotoToDTO(photo *models.Photo) *PhotoDTO {
+	return &PhotoDTO{
+		ID:            photo.ID,
+		URL:           config.PhotosBaseUrl + "/" + photo.Key,
+		ThumbnailURL:  config.PhotosBaseUrl + "/" + photo.ThumbnailKey,
+		Width:         photo.Width,
+		Height:        photo.Height,
+		InteractionID: photo.InteractionID,
+		CreatedAt:     photo.CreatedAt,
+	}
+}
+
+func buildPhotosDTO(photos []models.Photo) []PhotoDTO {
+	photosDTO := make([]PhotoDTO, len(photos))
+	for i := range photos {
+		photosDTO[i] = *PhotoToDTO(&photos[i])
+	}
+	return photosDTO
+}
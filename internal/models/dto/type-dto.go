@@ -3,17 +3,31 @@ package dto
 import "supmap-users/internal/models"
 
 type TypeDTO struct {
-	ID                int64  `json:"id"`
-	Name              string `json:"name"`
-	Description       string `json:"description"`
-	NeedRecalculation bool   `json:"need_recalculation"`
+	ID                          int64  `json:"id"`
+	Name                        string `json:"name"`
+	Description                 string `json:"description"`
+	NeedRecalculation           bool   `json:"need_recalculation"`
+	LifetimeWithoutConfirmation int    `json:"lifetime_without_confirmation"`
+	NegativeReportsThreshold    int    `json:"negative_reports_threshold"`
+	GlobalLifetime              int    `json:"global_lifetime"`
+	PositiveReportsThreshold    int    `json:"positive_reports_threshold"`
+	Disabled                    bool   `json:"disabled"`
+	// AttributesSchema est le schéma JSON des attributs des incidents de ce type,
+	// à partir duquel les clients peuvent construire le formulaire de signalement
+	AttributesSchema map[string]any `json:"attributes_schema,omitempty"`
 }
 
 func TypeToDTO(iType *models.Type) *TypeDTO {
 	return &TypeDTO{
-		ID:                iType.ID,
-		Name:              iType.Name,
-		Description:       iType.Description,
-		NeedRecalculation: iType.NeedRecalculation,
+		ID:                          iType.ID,
+		Name:                        iType.Name,
+		Description:                 iType.Description,
+		NeedRecalculation:           iType.NeedRecalculation,
+		LifetimeWithoutConfirmation: iType.LifetimeWithoutConfirmation,
+		NegativeReportsThreshold:    iType.NegativeReportsThreshold,
+		GlobalLifetime:              iType.GlobalLifetime,
+		PositiveReportsThreshold:    iType.PositiveReportsThreshold,
+		Disabled:                    iType.DisabledAt != nil,
+		AttributesSchema:            iType.AttributesSchema,
 	}
 }
@@ -1,10 +1,7 @@
 package dto
 
 import (
-	"encoding/json"
-	"fmt"
-	"net/http"
-	"supmap-users/internal/config"
+	"supmap-users/internal/models"
 )
 
 type RoleDTO struct {
@@ -17,21 +14,39 @@ type PartialUserDTO struct {
 	Role   *RoleDTO `json:"role"`
 }
 
-func UserIdToDTO(userId int64) (*PartialUserDTO, error) {
-	res, err := http.Get(fmt.Sprintf("%s/internal/users/%d", config.UsersBaseUrl, userId))
-	if err != nil {
-		return nil, err
-	}
+// Users associe les IDs des utilisateurs d'une réponse aux utilisateurs résolus auprès du service users
+type Users map[int64]*PartialUserDTO
 
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("status code %d returned for user id %d", res.StatusCode, userId)
+// Get retourne l'utilisateur correspondant à l'ID. Si l'utilisateur n'a pas pu être résolu
+// (service users indisponible, utilisateur supprimé), seul son ID est renseigné : le handle
+// est vide et le rôle nul.
+func (u Users) Get(id int64) *PartialUserDTO {
+	if user, ok := u[id]; ok {
+		return user
 	}
+	return &PartialUserDTO{ID: id}
+}
+
+// UserIDs regroupe sans doublon les IDs des utilisateurs à résoudre pour construire une réponse
+type UserIDs map[int64]struct{}
 
-	var body PartialUserDTO
-	err = json.NewDecoder(res.Body).Decode(&body)
-	if err != nil {
-		return nil, err
+// AddIncident ajoute l'auteur de l'incident, et ceux de ses interactions si elles sont incluses dans la réponse
+func (ids UserIDs) AddIncident(incident *models.Incident, interactionsState InteractionsResultState) {
+	if incident == nil {
+		return
 	}
+	ids[incident.UserID] = struct{}{}
+	if interactionsState == IncludeInteractions {
+		for _, interaction := range incident.Interactions {
+			ids[interaction.UserID] = struct{}{}
+		}
+	}
+}
 
-	return &body, nil
+// AddInteraction ajoute l'auteur de l'interaction, et les utilisateurs de son incident s'il est inclus dans la réponse
+func (ids UserIDs) AddInteraction(interaction *models.Interaction, includeIncidents InteractionsResultState) {
+	ids[interaction.UserID] = struct{}{}
+	if includeIncidents != Ignore {
+		ids.AddIncident(interaction.Incident, includeIncidents)
+	}
 }
@@ -0,0 +1,23 @@
+package dto
+
+type WebSocketResponseType string
+
+const (
+	WebSocketSubscribed   WebSocketResponseType = "subscribed"
+	WebSocketAreaUpdated  WebSocketResponseType = "area_updated"
+	WebSocketUnsubscribed WebSocketResponseType = "unsubscribed"
+	WebSocketPong         WebSocketResponseType = "pong"
+	WebSocketEvent        WebSocketResponseType = "event"
+	WebSocketReset        WebSocketResponseType = "reset"
+	WebSocketError        WebSocketResponseType = "error"
+)
+
+// WebSocketResponseDTO est un message envoyé par le serveur sur la connexion WebSocket.
+// ID, Action et Data ne sont renseignés que pour les messages de type event.
+type WebSocketResponseDTO struct {
+	Type   WebSocketResponseType `json:"type"`
+	ID     uint64                `json:"id,omitempty"`
+	Action string                `json:"action,omitempty"`
+	Data   *IncidentRedis        `json:"data,omitempty"`
+	Error  string                `json:"error,omitempty"`
+}
@@ -2,27 +2,90 @@ package models
 
 import (
 	"github.com/uptrace/bun"
+	"sort"
+	"supmap-users/internal/helpers"
 	"time"
 )
 
 type Incident struct {
 	bun.BaseModel `bun:"table:incidents,alias:i"`
 
-	ID        int64      `json:"id" bun:"id,pk,autoincrement"`
-	TypeID    int64      `json:"-" bun:"type_id,notnull"`
-	UserID    int64      `json:"user_id" bun:"user_id,notnull"`
-	Latitude  float64    `json:"lat" bun:"latitude,notnull"`
-	Longitude float64    `json:"lon" bun:"longitude,notnull"`
-	CreatedAt time.Time  `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
-	UpdatedAt time.Time  `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
-	DeletedAt *time.Time `json:"deleted_at,omitempty" bun:"deleted_at"`
+	ID             int64          `json:"id" bun:"id,pk,autoincrement"`
+	TypeID         int64          `json:"-" bun:"type_id,notnull"`
+	UserID         int64          `json:"user_id" bun:"user_id,notnull"`
+	Latitude       float64        `json:"lat" bun:"latitude,notnull"`
+	Longitude      float64        `json:"lon" bun:"longitude,notnull"`
+	Geohash        string         `json:"geohash" bun:"geohash,notnull"`
+	Heading        *float64       `json:"heading,omitempty" bun:"heading"`
+	BothDirections bool           `json:"both_directions" bun:"both_directions,notnull,default:false"`
+	Severity       string         `json:"severity" bun:"severity,notnull,default:'moderate'"`
+	Description    *string        `json:"description,omitempty" bun:"description"`
+	Attributes     map[string]any `json:"attributes,omitempty" bun:"attributes,type:jsonb,nullzero"`
+	CreatedAt      time.Time      `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+	UpdatedAt      time.Time      `json:"updated_at" bun:"updated_at,notnull,default:current_timestamp"`
+	DeletedAt      *time.Time     `json:"deleted_at,omitempty" bun:"deleted_at"`
 
 	// Relations
 	Type         *Type         `json:"type" bun:"rel:belongs-to,join:type_id=id"`
 	Interactions []Interaction `json:"interactions" bun:"rel:has-many,join:id=incident_id"`
+	Photos       []Photo       `json:"photos" bun:"rel:has-many,join:id=incident_id"`
 }
 
 type IncidentWithDistance struct {
+	Incident `bun:",extend"`
+	Distance float64 `json:"distance" bun:"distance,scanonly"`
+}
+
+type IncidentOnRoute struct {
 	Incident
-	Distance float64 `json:"distance" bun:"distance"`
+	AlongRouteDistance float64 `json:"along_route_distance"`
+	LateralDistance    float64 `json:"lateral_distance"`
+}
+
+// IsActive indique si l'incident est toujours en cours
+func (i *Incident) IsActive() bool {
+	return i.DeletedAt == nil
+}
+
+// AffectsHeading indique si l'incident concerne les véhicules circulant au cap donné.
+// Un incident sans cap ou signalé dans les deux sens concerne tous les véhicules.
+func (i *Incident) AffectsHeading(heading float64, tolerance float64) bool {
+	if i.Heading == nil || i.BothDirections {
+		return true
+	}
+	return helpers.HeadingDifference(*i.Heading, heading) <= tolerance
+}
+
+// SameDirection indique si un signalement peut être rattaché à l'incident selon le sens de circulation.
+// Un signalement ou un incident sans sens connu ou dans les deux sens est compatible avec tous les autres,
+// et deux caps sont compatibles si leur écart ne dépasse pas tolerance.
+func (i *Incident) SameDirection(heading *float64, bothDirections bool, tolerance float64) bool {
+	if heading == nil || bothDirections || i.Heading == nil || i.BothDirections {
+		return true
+	}
+	return helpers.HeadingDifference(*i.Heading, *heading) <= tolerance
+}
+
+// IsCertified indique si les dernières interactions de l'incident sont
+// suffisamment de confirmations consécutives pour atteindre le seuil du type
+func (i *Incident) IsCertified() bool {
+	if i.Type == nil || i.Type.PositiveReportsThreshold <= 0 {
+		return false
+	}
+
+	interactions := make([]Interaction, len(i.Interactions))
+	copy(interactions, i.Interactions)
+	sort.SliceStable(interactions, func(a, b int) bool {
+		return interactions[a].CreatedAt.After(interactions[b].CreatedAt)
+	})
+
+	positiveCount := 0
+	for _, interaction := range interactions {
+		if !interaction.IsStillPresent {
+			break
+		}
+		positiveCount++
+	}
+
+	return positiveCount >= i.Type.PositiveReportsThreshold
 }
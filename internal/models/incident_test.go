@@ -0,0 +1,69 @@
+package models
+
+import "testing"
+
+const tolerance = 60
+
+func heading(value float64) *float64 {
+	return &value
+}
+
+func TestAffectsHeading(t *testing.T) {
+	tests := []struct {
+		name     string
+		incident Incident
+		heading  float64
+		want     bool
+	}{
+		{"no heading", Incident{}, 90, true},
+		{"both directions", Incident{Heading: heading(0), BothDirections: true}, 180, true},
+		{"same heading", Incident{Heading: heading(90)}, 90, true},
+		{"within tolerance", Incident{Heading: heading(90)}, 150, true},
+		{"beyond tolerance", Incident{Heading: heading(90)}, 151, false},
+		{"opposite heading", Incident{Heading: heading(90)}, 270, false},
+		{"wraparound within tolerance", Incident{Heading: heading(350)}, 20, true},
+		{"wraparound at 0 and 360", Incident{Heading: heading(0)}, 360, true},
+		{"wraparound beyond tolerance", Incident{Heading: heading(330)}, 31, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.incident.AffectsHeading(tt.heading, tolerance); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSameDirection(t *testing.T) {
+	tests := []struct {
+		name           string
+		incident       Incident
+		heading        *float64
+		bothDirections bool
+		want           bool
+	}{
+		{"unknown report and incident", Incident{}, nil, false, true},
+		{"unknown report", Incident{Heading: heading(90)}, nil, false, true},
+		{"unknown incident", Incident{}, heading(90), false, true},
+		{"both directions incident and heading", Incident{Heading: heading(90), BothDirections: true}, heading(270), false, true},
+		{"both directions incident without heading", Incident{BothDirections: true}, heading(0), false, true},
+		{"both directions report", Incident{Heading: heading(90)}, heading(270), true, true},
+		{"both directions report and incident", Incident{BothDirections: true}, nil, true, true},
+		{"same heading", Incident{Heading: heading(90)}, heading(90), false, true},
+		{"within tolerance", Incident{Heading: heading(90)}, heading(30), false, true},
+		{"beyond tolerance", Incident{Heading: heading(90)}, heading(29), false, false},
+		{"opposite heading", Incident{Heading: heading(0)}, heading(180), false, false},
+		{"wraparound within tolerance", Incident{Heading: heading(10)}, heading(330), false, true},
+		{"wraparound at 0 and 360", Incident{Heading: heading(360)}, heading(0), false, true},
+		{"wraparound beyond tolerance", Incident{Heading: heading(300)}, heading(1), false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.incident.SameDirection(tt.heading, tt.bothDirections, tolerance); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
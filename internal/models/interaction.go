@@ -12,8 +12,9 @@ type Interaction struct {
 	IncidentID     int64     `json:"-" bun:"incident_id,notnull"`
 	UserID         int64     `json:"user_id" bun:"user_id,notnull"`
 	IsStillPresent bool      `json:"is_still_present" bun:"is_still_present,notnull"`
+	SeverityChange *string   `json:"severity_change,omitempty" bun:"severity_change"`
 	CreatedAt      time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
 
 	// Relations
 	Incident *Incident `json:"incident,omitempty" bun:"rel:belongs-to,join:incident_id=id"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type Photo struct {
+	bun.BaseModel `bun:"table:incident_photos,alias:ip"`
+
+	ID            int64     `json:"id" bun:"id,pk,autoincrement"`
+	IncidentID    int64     `json:"-" bun:"incident_id,notnull"`
+	InteractionID *int64    `json:"interaction_id,omitempty" bun:"interaction_id"`
+	UserID        int64     `json:"user_id" bun:"user_id,notnull"`
+	Key           string    `json:"key" bun:"key,notnull"`
+	ThumbnailKey  string    `json:"thumbnail_key" bun:"thumbnail_key,notnull"`
+	Width         int       `json:"width" bun:"width,notnull"`
+	Height        int       `json:"height" bun:"height,notnull"`
+	CreatedAt     time.Time `json:"created_at" bun:"created_at,notnull,default:current_timestamp"`
+}
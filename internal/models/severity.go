@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Niveaux de gravité des incidents, du moins grave au plus grave
+const (
+	SeverityMinor    = "minor"
+	SeverityModerate = "moderate"
+	SeverityMajor    = "major"
+	SeverityBlocking = "blocking"
+)
+
+// Évolutions de la gravité signalées par les interactions
+const (
+	SeverityWorse  = "worse"
+	SeverityBetter = "better"
+)
+
+// Severities liste les niveaux de gravité par ordre croissant
+var Severities = []string{SeverityMinor, SeverityModerate, SeverityMajor, SeverityBlocking}
+
+// ChangeSeverity fait évoluer la gravité de l'incident d'un niveau, sans dépasser les niveaux extrêmes.
+// Retourne false si la gravité n'a pas changé.
+func (i *Incident) ChangeSeverity(change string) bool {
+	level := slices.Index(Severities, i.Severity)
+	if level < 0 {
+		level = slices.Index(Severities, SeverityModerate)
+	}
+
+	switch change {
+	case SeverityWorse:
+		level = min(level+1, len(Severities)-1)
+	case SeverityBetter:
+		level = max(level-1, 0)
+	}
+
+	if Severities[level] == i.Severity {
+		return false
+	}
+	i.Severity = Severities[level]
+	return true
+}
+
+// SeverityChangeTowards retourne l'évolution à appliquer à l'incident pour se rapprocher de la gravité
+// signalée, ou nil si elle est identique ou inconnue
+func (i *Incident) SeverityChangeTowards(severity string) *string {
+	current, reported := slices.Index(Severities, i.Severity), slices.Index(Severities, severity)
+	if reported < 0 || current == reported {
+		return nil
+	}
+
+	change := SeverityWorse
+	if reported < current {
+		change = SeverityBetter
+	}
+	return &change
+}
+
+// SeverityLifetime applique à une durée de vie en secondes le facteur correspondant à la gravité de
+// l'incident (SEVERITY_LIFETIME_FACTORS), la durée est inchangée si aucun facteur n'est défini
+func (i *Incident) SeverityLifetime(seconds int, factors map[string]float64) time.Duration {
+	lifetime := time.Duration(seconds) * time.Second
+	if factor, ok := factors[i.Severity]; ok && factor > 0 {
+		lifetime = time.Duration(float64(lifetime) * factor)
+	}
+	return lifetime
+}
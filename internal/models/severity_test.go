@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeSeverity(t *testing.T) {
+	tests := []struct {
+		name     string
+		severity string
+		change   string
+		want     string
+		changed  bool
+	}{
+		{"worse from minor", SeverityMinor, SeverityWorse, SeverityModerate, true},
+		{"worse from major", SeverityMajor, SeverityWorse, SeverityBlocking, true},
+		{"worse clamped at blocking", SeverityBlocking, SeverityWorse, SeverityBlocking, false},
+		{"better from blocking", SeverityBlocking, SeverityBetter, SeverityMajor, true},
+		{"better from moderate", SeverityModerate, SeverityBetter, SeverityMinor, true},
+		{"better clamped at minor", SeverityMinor, SeverityBetter, SeverityMinor, false},
+		{"unknown change", SeverityMajor, "same", SeverityMajor, false},
+		{"unknown severity treated as moderate", "unknown", SeverityWorse, SeverityMajor, true},
+		{"unknown severity replaced by moderate", "", "same", SeverityModerate, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := &Incident{Severity: tt.severity}
+			changed := incident.ChangeSeverity(tt.change)
+			if incident.Severity != tt.want || changed != tt.changed {
+				t.Errorf("ChangeSeverity(%q) from %q = %q, %t, want %q, %t", tt.change, tt.severity, incident.Severity, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestSeverityChangeTowards(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  string
+		reported string
+		want     string
+	}{
+		{"more severe", SeverityModerate, SeverityBlocking, SeverityWorse},
+		{"less severe", SeverityBlocking, SeverityMinor, SeverityBetter},
+		{"one level above", SeverityMinor, SeverityModerate, SeverityWorse},
+		{"same severity", SeverityMajor, SeverityMajor, ""},
+		{"unknown reported severity", SeverityMajor, "critical", ""},
+		{"empty reported severity", SeverityMinor, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&Incident{Severity: tt.current}).SeverityChangeTowards(tt.reported)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Errorf("expected no change, got %q", *got)
+			case tt.want != "" && (got == nil || *got != tt.want):
+				t.Errorf("expected %q, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// Un incident signalé plusieurs fois comme plus grave atteint le niveau signalé sans le dépasser
+func TestSeverityConvergesTowardsReport(t *testing.T) {
+	incident := &Incident{Severity: SeverityMinor}
+	for range len(Severities) + 1 {
+		if change := incident.SeverityChangeTowards(SeverityMajor); change != nil {
+			incident.ChangeSeverity(*change)
+		}
+	}
+	if incident.Severity != SeverityMajor {
+		t.Errorf("expected %q, got %q", SeverityMajor, incident.Severity)
+	}
+}
+
+func TestSeverityLifetime(t *testing.T) {
+	factors := map[string]float64{SeverityMinor: 0.5, SeverityBlocking: 2, SeverityMajor: 0}
+
+	tests := []struct {
+		name     string
+		severity string
+		want     time.Duration
+	}{
+		{"reduced lifetime", SeverityMinor, 30 * time.Minute},
+		{"extended lifetime", SeverityBlocking, 2 * time.Hour},
+		{"no factor", SeverityModerate, time.Hour},
+		{"zero factor ignored", SeverityMajor, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incident := &Incident{Severity: tt.severity}
+			if got := incident.SeverityLifetime(3600, factors); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	if got := (&Incident{Severity: SeverityMinor}).SeverityLifetime(3600, nil); got != time.Hour {
+		t.Errorf("expected lifetime unchanged without factors, got %v", got)
+	}
+}
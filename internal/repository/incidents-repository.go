@@ -6,6 +6,7 @@ import (
 	"errors"
 	"github.com/uptrace/bun"
 	"log/slog"
+	"supmap-users/internal/helpers"
 	"supmap-users/internal/models"
 	"supmap-users/internal/models/dto"
 	"time"
@@ -14,6 +15,8 @@ import (
 type Incidents struct {
 	log *slog.Logger
 	bun *bun.DB
+	// postgis indique si la colonne geog, créée uniquement si l'extension PostGIS est disponible, existe
+	postgis bool
 }
 
 func NewIncidents(db *bun.DB, log *slog.Logger) *Incidents {
@@ -23,6 +26,30 @@ func NewIncidents(db *bun.DB, log *slog.Logger) *Incidents {
 	}
 }
 
+// DetectPostGIS vérifie la présence de la colonne geog pour choisir le mode de calcul des recherches par rayon.
+// Seule FindIncidentsInZone en dépend : les recherches par zone rectangulaire, le long d'un itinéraire et la
+// synchronisation différentielle utilisent toujours l'index incidents_lat_long_idx.
+// La détection n'est faite qu'une fois, au démarrage du service.
+func (i *Incidents) DetectPostGIS(ctx context.Context) error {
+	exists, err := i.bun.NewSelect().
+		Table("information_schema.columns").
+		Where("table_schema = current_schema()").
+		Where("table_name = 'incidents'").
+		Where("column_name = 'geog'").
+		Exists(ctx)
+	if err != nil {
+		return err
+	}
+
+	i.postgis = exists
+	if exists {
+		i.log.Info("PostGIS geography column detected at startup, radius queries use ST_DWithin", "postgis", exists)
+	} else {
+		i.log.Info("PostGIS geography column not found at startup, radius queries use the haversine formula until the service is restarted", "postgis", exists)
+	}
+	return nil
+}
+
 func (i *Incidents) GetAllActive(ctx context.Context, exec bun.IDB) ([]models.Incident, error) {
 	var types []models.Incident
 	err := exec.NewSelect().
@@ -45,6 +72,7 @@ func (i *Incidents) FindAllIncidentTypes(ctx context.Context) ([]models.Type, er
 	var types []models.Type
 	err := i.bun.NewSelect().
 		Model(&types).
+		Where("disabled_at IS NULL").
 		Order("id ASC").
 		Scan(ctx)
 
@@ -76,6 +104,28 @@ func (i *Incidents) FindIncidentTypeById(ctx context.Context, id *int64) (*model
 	return &incidentType, nil
 }
 
+func (i *Incidents) CreateIncidentType(ctx context.Context, incidentType *models.Type) error {
+	if _, err := i.bun.NewInsert().Model(incidentType).Returning("id").Exec(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (i *Incidents) UpdateIncidentType(ctx context.Context, incidentType *models.Type) error {
+	// Toutes les colonnes sont mises à jour pour permettre de passer need_recalculation à false
+	_, err := i.bun.NewUpdate().
+		Model(incidentType).
+		Where("id = ?", incidentType.ID).
+		ExcludeColumn("id").
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (i *Incidents) FindIncidentByIdTx(ctx context.Context, exec bun.IDB, id int64) (*models.Incident, error) {
 	var incident models.Incident
 
@@ -97,6 +147,7 @@ func (i *Incidents) FindIncidentByIdTx(ctx context.Context, exec bun.IDB, id int
 		Model(&incident).
 		Relation("Type").
 		Relation("Interactions"). // Charger les relations après
+		Relation("Photos").
 		Where("i.id = ?", id).
 		Scan(ctx)
 
@@ -111,24 +162,78 @@ func (i *Incidents) FindIncidentById(ctx context.Context, id int64) (*models.Inc
 	return i.FindIncidentByIdTx(ctx, i.bun, id)
 }
 
-func (i *Incidents) FindUserHistory(ctx context.Context, user *dto.PartialUserDTO) ([]models.Incident, error) {
+// FindUserHistory godoc
+// Récupère une page des incidents expirés de l'utilisateur ainsi que le nombre total
+// d'incidents correspondant aux filtres
+func (i *Incidents) FindUserHistory(ctx context.Context, user *dto.PartialUserDTO, filters *HistoryFilters, page *PageQuery) ([]models.Incident, int, error) {
 	var incidents []models.Incident
 
-	err := i.bun.NewSelect().
+	history := func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			Where("i.user_id = ?", user.ID).
+			Where("i.deleted_at IS NOT NULL").
+			Apply(filters.apply)
+	}
+
+	total, err := i.bun.NewSelect().
+		Model((*models.Incident)(nil)).
+		Apply(history).
+		Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = i.bun.NewSelect().
 		Model(&incidents).
 		Relation("Type").
 		Relation("Interactions").
-		Where("i.user_id = ?", user.ID).
-		Where("i.deleted_at IS NOT NULL").
+		Relation("Photos").
+		Apply(history, page.apply("i")).
 		Scan(ctx)
 
 	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+		return nil, total, nil
 	} else if err != nil {
-		return nil, err
+		return nil, 0, err
+	}
+
+	return incidents, total, nil
+}
+
+// FindUserActiveIncidents godoc
+// Récupère une page des incidents en cours de l'utilisateur ainsi que leur nombre total
+func (i *Incidents) FindUserActiveIncidents(ctx context.Context, user *dto.PartialUserDTO, page *PageQuery) ([]models.Incident, int, error) {
+	var incidents []models.Incident
+
+	active := func(q *bun.SelectQuery) *bun.SelectQuery {
+		return q.
+			Where("i.user_id = ?", user.ID).
+			Where("i.deleted_at IS NULL")
+	}
+
+	total, err := i.bun.NewSelect().
+		Model((*models.Incident)(nil)).
+		Apply(active).
+		Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = i.bun.NewSelect().
+		Model(&incidents).
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos").
+		Apply(active, page.apply("i")).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, total, nil
+	} else if err != nil {
+		return nil, 0, err
 	}
 
-	return incidents, err
+	return incidents, total, nil
 }
 
 func (i *Incidents) GetLastUserIncident(ctx context.Context, user *dto.PartialUserDTO) (*models.Incident, error) {
@@ -137,6 +242,7 @@ func (i *Incidents) GetLastUserIncident(ctx context.Context, user *dto.PartialUs
 		Model(&incident).
 		Relation("Type").
 		Relation("Interactions").
+		Relation("Photos").
 		Where("i.user_id = ?", user.ID).
 		OrderExpr("i.created_at DESC").
 		Limit(1).
@@ -151,44 +257,81 @@ func (i *Incidents) GetLastUserIncident(ctx context.Context, user *dto.PartialUs
 	return &incident, nil
 }
 
+// FindIncidentsInZone godoc
+// Récupère les incidents actifs dans un rayon autour d'un point, triés par distance, avec leur type
+// et leurs interactions. Le type est joint à la requête principale et les interactions sont chargées
+// en une seule requête supplémentaire, quel que soit le nombre d'incidents et sans verrouiller les lignes.
+// Si la colonne geog est disponible, la recherche utilise PostGIS et son index GiST.
 func (i *Incidents) FindIncidentsInZone(ctx context.Context, lat, lon *float64, radius int64, typeId *int64) ([]models.IncidentWithDistance, error) {
 	var incidents []models.IncidentWithDistance
 
-	rawSQL := `
-		SELECT *
-		FROM (SELECT *,
-					 (
-						 6371000 * acos(
-								 cos(radians(?)) * cos(radians(latitude)) *
-								 cos(radians(longitude) - radians(?)) +
-								 sin(radians(?)) * sin(radians(latitude))
-								   )
-						 ) AS distance
-			  FROM incidents
-			  WHERE deleted_at IS NULL
-				AND latitude BETWEEN (? - ? / 111000.0) AND (? + ? / 111000.0)
-				AND longitude BETWEEN (? - ? / 111000.0) AND (? + ? / 111000.0)
-		`
-
-	args := []any{
-		lat, lon, lat,
-		lat, radius, lat, radius,
-		lon, radius, lon, radius,
+	query := i.bun.NewSelect().
+		Model(&incidents).
+		ColumnExpr("?TableColumns").
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos").
+		Where("i.deleted_at IS NULL")
+
+	if i.postgis {
+		point := bun.SafeQuery("ST_SetSRID(ST_MakePoint(?, ?), 4326)::geography", *lon, *lat)
+		query = query.
+			ColumnExpr("ST_Distance(i.geog, ?) AS distance", point).
+			Where("ST_DWithin(i.geog, ?, ?)", point, radius)
+	} else {
+		// Formule de haversine, l'argument de asin est borné pour éviter un NaN dû aux arrondis
+		distance := bun.SafeQuery(`2 * ? * asin(LEAST(1, sqrt(
+			power(sin(radians(i.latitude - ?) / 2), 2) +
+			cos(radians(?)) * cos(radians(i.latitude)) * power(sin(radians(i.longitude - ?) / 2), 2)
+		)))`, helpers.EarthRadius, *lat, *lat, *lon)
+
+		// La zone rectangulaire englobant le cercle permet d'utiliser l'index incidents_lat_long_idx
+		box := helpers.ExpandBoundingBox(helpers.BoundingBox{MinLat: *lat, MinLon: *lon, MaxLat: *lat, MaxLon: *lon}, float64(radius))
+		query = whereInBoundingBox(query, &box).
+			ColumnExpr("? AS distance", distance).
+			Where("? <= ?", distance, radius)
 	}
 
 	if typeId != nil {
-		rawSQL += " AND type_id = ?"
-		args = append(args, typeId)
+		query = query.Where("i.type_id = ?", typeId)
+	}
+
+	err := query.
+		OrderExpr("distance").
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
 	}
 
-	rawSQL += `
-		) AS sub
-		WHERE distance <= ?
-		ORDER BY distance;
-		`
-	args = append(args, radius)
+	return incidents, nil
+}
+
+// FindIncidentsInBoundingBox godoc
+// Récupère au plus limit incidents actifs dans la zone, du plus récent au plus ancien.
+// Les bornes sur latitude et longitude permettent d'utiliser l'index incidents_lat_long_idx.
+func (i *Incidents) FindIncidentsInBoundingBox(ctx context.Context, box *helpers.BoundingBox, typeId *int64, limit int) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	query := i.bun.NewSelect().
+		Model(&incidents).
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos").
+		Where("i.deleted_at IS NULL")
+
+	query = whereInBoundingBox(query, box)
+
+	if typeId != nil {
+		query = query.Where("i.type_id = ?", typeId)
+	}
 
-	err := i.bun.NewRaw(rawSQL, args...).Scan(ctx, &incidents)
+	err := query.
+		OrderExpr("i.created_at DESC").
+		Limit(limit).
+		Scan(ctx)
 
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
@@ -199,13 +342,205 @@ func (i *Incidents) FindIncidentsInZone(ctx context.Context, lat, lon *float64,
 	return incidents, nil
 }
 
-func (i *Incidents) CreateIncident(ctx context.Context, incident *models.Incident) error {
-	if _, err := i.bun.NewInsert().Model(incident).Returning("id").Exec(ctx); err != nil {
+// FindIncidentsByGeohashPrefix godoc
+// Récupère au plus limit incidents actifs de la cellule geohash, du plus récent au plus ancien.
+// La recherche par préfixe utilise l'index incidents_geohash_idx (text_pattern_ops),
+// le préfixe ne doit contenir que des caractères geohash.
+func (i *Incidents) FindIncidentsByGeohashPrefix(ctx context.Context, prefix string, typeId *int64, limit int) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	query := i.bun.NewSelect().
+		Model(&incidents).
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos").
+		Where("i.deleted_at IS NULL").
+		Where("i.geohash LIKE ?", prefix+"%")
+
+	if typeId != nil {
+		query = query.Where("i.type_id = ?", typeId)
+	}
+
+	err := query.
+		OrderExpr("i.created_at DESC").
+		Limit(limit).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// FindIncidentsInBoundingBoxes godoc
+// Récupère les incidents actifs présents dans au moins une des zones
+func (i *Incidents) FindIncidentsInBoundingBoxes(ctx context.Context, boxes []helpers.BoundingBox, typeId *int64) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	query := i.bun.NewSelect().
+		Model(&incidents).
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos").
+		Where("i.deleted_at IS NULL").
+		WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, box := range boxes {
+				q = q.WhereGroup(" OR ", func(q *bun.SelectQuery) *bun.SelectQuery {
+					return whereInBoundingBox(q, &box)
+				})
+			}
+			return q
+		})
+
+	if typeId != nil {
+		query = query.Where("i.type_id = ?", typeId)
+	}
+
+	err := query.Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// FindIncidentChanges godoc
+// Récupère les incidents de la zone modifiés après le jeton, du plus ancien au plus récent.
+// Sans jeton, seuls les incidents en cours sont retournés.
+func (i *Incidents) FindIncidentChanges(ctx context.Context, box *helpers.BoundingBox, since *helpers.SyncToken, limit int) ([]models.Incident, error) {
+	var incidents []models.Incident
+
+	query := i.bun.NewSelect().
+		Model(&incidents).
+		Relation("Type").
+		Relation("Interactions").
+		Relation("Photos")
+
+	query = whereInBoundingBox(query, box)
+
+	if since != nil {
+		query = query.Where("(i.updated_at, i.id) > (?, ?)", since.UpdatedAt, since.ID)
+	} else {
+		query = query.Where("i.deleted_at IS NULL")
+	}
+
+	err := query.
+		OrderExpr("i.updated_at ASC, i.id ASC").
+		Limit(limit).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return incidents, nil
+}
+
+// FindIncidentCells godoc
+// Compte les incidents actifs de la zone par cellule geohash de precision caractères et par type.
+// Les sommes des coordonnées permettent de calculer le barycentre des cellules regroupant plusieurs types.
+func (i *Incidents) FindIncidentCells(ctx context.Context, box *helpers.BoundingBox, typeId *int64, precision int) ([]models.IncidentCell, error) {
+	var cells []models.IncidentCell
+
+	query := i.bun.NewSelect().
+		Model((*models.Incident)(nil)).
+		ColumnExpr("substr(i.geohash, 1, ?) AS geohash", precision).
+		ColumnExpr("i.type_id").
+		ColumnExpr("count(*) AS count").
+		ColumnExpr("sum(i.latitude)::float8 AS sum_lat").
+		ColumnExpr("sum(i.longitude)::float8 AS sum_lon").
+		ColumnExpr("min(i.latitude)::float8 AS min_lat").
+		ColumnExpr("min(i.longitude)::float8 AS min_lon").
+		ColumnExpr("max(i.latitude)::float8 AS max_lat").
+		ColumnExpr("max(i.longitude)::float8 AS max_lon").
+		Where("i.deleted_at IS NULL")
+	query = whereInBoundingBox(query, box)
+
+	if typeId != nil {
+		query = query.Where("i.type_id = ?", typeId)
+	}
+
+	err := query.
+		GroupExpr("1, 2").
+		OrderExpr("1, 2").
+		Scan(ctx, &cells)
+	if err != nil {
+		return nil, err
+	}
+
+	return cells, nil
+}
+
+func whereInBoundingBox(q *bun.SelectQuery, box *helpers.BoundingBox) *bun.SelectQuery {
+	q = q.Where("i.latitude BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+
+	if box.CrossesAntimeridian() {
+		// La zone est découpée en deux intervalles de part et d'autre de l'antiméridien
+		return q.WhereGroup(" AND ", func(q *bun.SelectQuery) *bun.SelectQuery {
+			for _, part := range box.Split() {
+				q = q.WhereOr("i.longitude BETWEEN ? AND ?", part.MinLon, part.MaxLon)
+			}
+			return q
+		})
+	}
+
+	return q.Where("i.longitude BETWEEN ? AND ?", box.MinLon, box.MaxLon)
+}
+
+func (i *Incidents) CreateIncidentTx(ctx context.Context, exec bun.IDB, incident *models.Incident) error {
+	if _, err := exec.NewInsert().Model(incident).Returning("id").Exec(ctx); err != nil {
 		return err
 	}
 	return nil
 }
 
+func (i *Incidents) CreateIncident(ctx context.Context, incident *models.Incident) error {
+	return i.CreateIncidentTx(ctx, i.bun, incident)
+}
+
+func (i *Incidents) InsertPhotosTx(ctx context.Context, exec bun.IDB, photos []models.Photo) error {
+	if len(photos) == 0 {
+		return nil
+	}
+	_, err := exec.NewInsert().
+		Model(&photos).
+		Returning("id").
+		Exec(ctx)
+	return err
+}
+
+// FindInteractionPhotosTx récupère les photos jointes à une interaction
+func (i *Incidents) FindInteractionPhotosTx(ctx context.Context, exec bun.IDB, interactionID int64) ([]models.Photo, error) {
+	var photos []models.Photo
+	err := exec.NewSelect().
+		Model(&photos).
+		Where("ip.interaction_id = ?", interactionID).
+		Scan(ctx)
+	return photos, err
+}
+
+// UpdateIncidentDescriptionTx enregistre la description de l'incident, y compris lorsqu'elle est retirée.
+// updated_at est mis à jour, ce qui compte aussi comme une confirmation pour l'expiration automatique
+func (i *Incidents) UpdateIncidentDescriptionTx(ctx context.Context, exec bun.IDB, incident *models.Incident) error {
+	incident.UpdatedAt = time.Now()
+
+	_, err := exec.NewUpdate().
+		Model(incident).
+		Column("description", "updated_at").
+		Where("id = ?", incident.ID).
+		Exec(ctx)
+
+	return err
+}
+
 func (i *Incidents) UpdateIncidentTx(ctx context.Context, exec bun.IDB, incident *models.Incident) error {
 	incident.UpdatedAt = time.Now()
 
@@ -221,3 +556,21 @@ func (i *Incidents) UpdateIncidentTx(ctx context.Context, exec bun.IDB, incident
 
 	return nil
 }
+
+func (i *Incidents) RestoreIncidentTx(ctx context.Context, exec bun.IDB, incident *models.Incident) error {
+	incident.DeletedAt = nil
+	incident.UpdatedAt = time.Now()
+
+	// OmitZero ne permet pas de remettre deleted_at à NULL, les colonnes sont donc explicitées
+	_, err := exec.NewUpdate().
+		Model(incident).
+		Column("deleted_at", "updated_at").
+		Where("id = ?", incident.ID).
+		Exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
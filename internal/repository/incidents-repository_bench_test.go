@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"io"
+	"log/slog"
+	"math/rand"
+	"os"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"testing"
+)
+
+const (
+	benchIncidents = 500
+	// benchUserID identifie les incidents créés par le benchmark pour les supprimer ensuite
+	benchUserID = -1
+	benchLat    = 48.8566
+	benchLon    = 2.3522
+	benchRadius = 2000
+)
+
+// BenchmarkFindIncidentsInZone compare la recherche par rayon avec PostGIS et avec la formule de haversine.
+// Le benchmark nécessite une base migrée, accessible via la variable d'environnement DB_URL.
+func BenchmarkFindIncidentsInZone(b *testing.B) {
+	dbUrl := os.Getenv("DB_URL")
+	if dbUrl == "" {
+		b.Skip("DB_URL is not set")
+	}
+
+	conn, err := sql.Open("pgx", dbUrl)
+	if err != nil {
+		b.Fatal(err)
+	}
+	db := bun.NewDB(conn, pgdialect.New())
+	defer func() {
+		_ = db.Close()
+	}()
+
+	ctx := context.Background()
+	incidents := NewIncidents(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := incidents.DetectPostGIS(ctx); err != nil {
+		b.Fatal(err)
+	}
+	postgis := incidents.postgis
+
+	seedIncidents(b, ctx, db)
+
+	lat, lon := benchLat, benchLon
+	for _, mode := range []struct {
+		name    string
+		postgis bool
+	}{
+		{name: "postgis", postgis: true},
+		{name: "haversine", postgis: false},
+	} {
+		b.Run(mode.name, func(b *testing.B) {
+			if mode.postgis && !postgis {
+				b.Skip("geog column is not available")
+			}
+			incidents.postgis = mode.postgis
+
+			found, err := incidents.FindIncidentsInZone(ctx, &lat, &lon, benchRadius, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if len(found) < benchIncidents {
+				b.Fatalf("expected at least %d incidents in range, got %d", benchIncidents, len(found))
+			}
+
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				if _, err := incidents.FindIncidentsInZone(ctx, &lat, &lon, benchRadius, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// seedIncidents crée benchIncidents incidents actifs répartis dans le cercle de recherche du benchmark
+func seedIncidents(b *testing.B, ctx context.Context, db *bun.DB) {
+	var incidentType models.Type
+	if err := db.NewSelect().Model(&incidentType).Limit(1).Scan(ctx); err != nil {
+		b.Skipf("no incident type available: %v", err)
+	}
+
+	// Les points sont tirés dans la zone englobant le cercle et conservés s'ils sont dans le rayon,
+	// avec une marge pour que les écarts entre PostGIS et haversine ne les excluent pas
+	const seedRadius = benchRadius * 0.95
+	box := helpers.ExpandBoundingBox(helpers.BoundingBox{MinLat: benchLat, MinLon: benchLon, MaxLat: benchLat, MaxLon: benchLon}, seedRadius)
+
+	random := rand.New(rand.NewSource(1))
+	seeded := make([]models.Incident, 0, benchIncidents)
+	for len(seeded) < benchIncidents {
+		lat := box.MinLat + random.Float64()*(box.MaxLat-box.MinLat)
+		lon := box.MinLon + random.Float64()*(box.MaxLon-box.MinLon)
+		if helpers.Distance(benchLat, benchLon, lat, lon) > seedRadius {
+			continue
+		}
+		seeded = append(seeded, models.Incident{
+			TypeID:    incidentType.ID,
+			UserID:    benchUserID,
+			Latitude:  lat,
+			Longitude: lon,
+			Geohash:   helpers.EncodeGeohash(lat, lon, helpers.GeohashPrecision),
+			Severity:  models.SeverityModerate,
+		})
+	}
+
+	if _, err := db.NewInsert().Model(&seeded).Exec(ctx); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		_, _ = db.NewDelete().Model((*models.Incident)(nil)).Where("user_id = ?", benchUserID).Exec(context.Background())
+	})
+}
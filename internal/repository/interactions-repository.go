@@ -7,6 +7,7 @@ import (
 	"github.com/uptrace/bun"
 	"log/slog"
 	"supmap-users/internal/models"
+	"supmap-users/internal/models/dto"
 )
 
 type Interactions struct {
@@ -37,6 +38,7 @@ func (i *Interactions) FindInteractionByIdTx(ctx context.Context, exec bun.IDB,
 		Relation("Incident").
 		Relation("Incident.Type").
 		Relation("Incident.Interactions").
+		Relation("Incident.Photos").
 		Where("ii.id = ?", id).
 		Scan(ctx)
 
@@ -52,3 +54,44 @@ func (i *Interactions) FindInteractionByIdTx(ctx context.Context, exec bun.IDB,
 func (i *Interactions) FindInteractionById(ctx context.Context, id int64) (*models.Interaction, error) {
 	return i.FindInteractionByIdTx(ctx, i.bun, id)
 }
+
+func (i *Interactions) DeleteTx(ctx context.Context, exec bun.IDB, interaction *models.Interaction) error {
+	_, err := exec.NewDelete().
+		Model(interaction).
+		Where("id = ?", interaction.ID).
+		Exec(ctx)
+	return err
+}
+
+// FindUserInteractions godoc
+// Récupère une page des interactions de l'utilisateur, avec l'incident associé, ainsi que leur nombre total
+func (i *Interactions) FindUserInteractions(ctx context.Context, user *dto.PartialUserDTO, page *PageQuery) ([]models.Interaction, int, error) {
+	var interactions []models.Interaction
+
+	// Utilise l'index interactions_user_idx
+	total, err := i.bun.NewSelect().
+		Model((*models.Interaction)(nil)).
+		Where("ii.user_id = ?", user.ID).
+		Count(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	err = i.bun.NewSelect().
+		Model(&interactions).
+		Relation("Incident").
+		Relation("Incident.Type").
+		Relation("Incident.Interactions").
+		Relation("Incident.Photos").
+		Where("ii.user_id = ?", user.ID).
+		Apply(page.apply("ii")).
+		Scan(ctx)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, total, nil
+	} else if err != nil {
+		return nil, 0, err
+	}
+
+	return interactions, total, nil
+}
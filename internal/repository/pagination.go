@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"github.com/uptrace/bun"
+	"supmap-users/internal/helpers"
+	"time"
+)
+
+// PageQuery décrit une page d'une liste triée de la plus récente à la plus ancienne
+type PageQuery struct {
+	Cursor *helpers.Cursor
+	Limit  int
+}
+
+// apply filtre les éléments situés après le curseur (pagination par clé sur created_at, id)
+// et demande un élément supplémentaire pour savoir s'il existe une page suivante
+func (p *PageQuery) apply(alias string) func(*bun.SelectQuery) *bun.SelectQuery {
+	return func(q *bun.SelectQuery) *bun.SelectQuery {
+		if p.Cursor != nil {
+			q = q.Where("(?.created_at, ?.id) < (?, ?)", bun.Ident(alias), bun.Ident(alias), p.Cursor.CreatedAt, p.Cursor.ID)
+		}
+
+		return q.
+			OrderExpr("?.created_at DESC, ?.id DESC", bun.Ident(alias), bun.Ident(alias)).
+			Limit(p.Limit + 1)
+	}
+}
+
+type HistoryFilters struct {
+	From   *time.Time
+	To     *time.Time
+	Before *time.Time
+	TypeId *int64
+}
+
+func (f *HistoryFilters) apply(q *bun.SelectQuery) *bun.SelectQuery {
+	if f.From != nil {
+		q = q.Where("i.created_at >= ?", f.From)
+	}
+	if f.To != nil {
+		q = q.Where("i.created_at <= ?", f.To)
+	}
+	if f.Before != nil {
+		q = q.Where("i.created_at < ?", f.Before)
+	}
+	if f.TypeId != nil {
+		q = q.Where("i.type_id = ?", f.TypeId)
+	}
+	return q
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"reflect"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"sync"
+)
+
+// attributesSchemas conserve le schéma compilé des attributs de chaque type d'incident. Le schéma est
+// compilé à la création ou à la modification du type, ou au premier signalement après le démarrage,
+// puis réutilisé tant que le schéma enregistré pour le type est identique.
+type attributesSchemas struct {
+	mu      sync.RWMutex
+	schemas map[int64]compiledSchema
+}
+
+type compiledSchema struct {
+	// source est le schéma à partir duquel compiled a été obtenu, pour détecter une modification
+	// faite par une autre instance
+	source   map[string]any
+	compiled *helpers.JSONSchema
+}
+
+func newAttributesSchemas() *attributesSchemas {
+	return &attributesSchemas{schemas: make(map[int64]compiledSchema)}
+}
+
+// get retourne le schéma compilé du type, nil si le type n'a pas de schéma
+func (a *attributesSchemas) get(incidentType *models.Type) (*helpers.JSONSchema, error) {
+	if incidentType.AttributesSchema == nil {
+		return nil, nil
+	}
+
+	a.mu.RLock()
+	cached, ok := a.schemas[incidentType.ID]
+	a.mu.RUnlock()
+	if ok && reflect.DeepEqual(cached.source, incidentType.AttributesSchema) {
+		return cached.compiled, nil
+	}
+
+	compiled, err := helpers.CompileJSONSchema(incidentType.AttributesSchema)
+	if err != nil {
+		return nil, err
+	}
+	a.set(incidentType.ID, incidentType.AttributesSchema, compiled)
+	return compiled, nil
+}
+
+// set conserve le schéma compilé d'un type, ou l'oublie si le type n'a plus de schéma
+func (a *attributesSchemas) set(id int64, source map[string]any, compiled *helpers.JSONSchema) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if compiled == nil {
+		delete(a.schemas, id)
+		return
+	}
+	a.schemas[id] = compiledSchema{source: source, compiled: compiled}
+}
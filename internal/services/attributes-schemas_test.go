@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/models"
+	"testing"
+)
+
+func TestAttributesSchemas(t *testing.T) {
+	schemas := newAttributesSchemas()
+	incidentType := &models.Type{ID: 1, AttributesSchema: map[string]any{"type": "object"}}
+
+	first, err := schemas.get(incidentType)
+	if err != nil || first == nil {
+		t.Fatalf("expected a compiled schema, got %v, %v", first, err)
+	}
+
+	// Le type est relu en base à chaque signalement : un schéma identique n'est pas recompilé
+	reloaded := &models.Type{ID: 1, AttributesSchema: map[string]any{"type": "object"}}
+	if second, _ := schemas.get(reloaded); second != first {
+		t.Error("expected the compiled schema to be reused")
+	}
+
+	// Un schéma modifié, par exemple par une autre instance, est recompilé
+	changed := &models.Type{ID: 1, AttributesSchema: map[string]any{"type": "object", "required": []any{"lanes"}}}
+	third, err := schemas.get(changed)
+	if err != nil || third == first {
+		t.Fatalf("expected the changed schema to be compiled, got %v", err)
+	}
+	if errs := third.Validate(map[string]any{}); errs["/lanes"] != "is required" {
+		t.Errorf("expected the changed schema to be used, got %v", errs)
+	}
+
+	withoutSchema := &models.Type{ID: 2}
+	if compiled, err := schemas.get(withoutSchema); compiled != nil || err != nil {
+		t.Errorf("expected no schema, got %v, %v", compiled, err)
+	}
+
+	if _, err := schemas.get(&models.Type{ID: 3, AttributesSchema: map[string]any{"type": "string"}}); err == nil {
+		t.Error("expected invalid schema error")
+	}
+	if _, ok := schemas.schemas[3]; ok {
+		t.Error("expected invalid schema not to be cached")
+	}
+
+	schemas.set(1, nil, nil)
+	if _, ok := schemas.schemas[1]; ok {
+		t.Error("expected removed schema to be forgotten")
+	}
+}
+
+func TestCheckAttributes(t *testing.T) {
+	s := &Service{schemas: newAttributesSchemas()}
+	withSchema := &models.Type{ID: 1, AttributesSchema: map[string]any{
+		"type":     "object",
+		"required": []any{"lanes"},
+		"properties": map[string]any{
+			"lanes": map[string]any{"type": "integer", "minimum": float64(1)},
+		},
+	}}
+	withoutSchema := &models.Type{ID: 2}
+
+	tests := []struct {
+		name         string
+		incidentType *models.Type
+		attributes   map[string]any
+		wantCode     int
+		wantDetails  map[string]string
+	}{
+		{"valid attributes", withSchema, map[string]any{"lanes": float64(2)}, 0, nil},
+		{"missing attributes", withSchema, nil, 0, map[string]string{"attributes/lanes": "is required"}},
+		{"invalid attribute", withSchema, map[string]any{"lanes": float64(0)}, 0, map[string]string{"attributes/lanes": "must be greater than or equal to 1"}},
+		{"no schema and no attributes", withoutSchema, nil, 0, nil},
+		{"no schema with attributes", withoutSchema, map[string]any{"lanes": float64(2)}, http.StatusBadRequest, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkAttributes(tt.incidentType, tt.attributes)
+
+			switch {
+			case tt.wantCode != 0:
+				if ewc := DecodeErrorWithCode(err); ewc == nil || ewc.Code != tt.wantCode {
+					t.Errorf("expected error code %d, got %v", tt.wantCode, err)
+				}
+			case tt.wantDetails != nil:
+				var validationErr *validations.ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("expected a validation error, got %v", err)
+				}
+				if !maps.Equal(validationErr.Details, tt.wantDetails) {
+					t.Errorf("expected %v, got %v", tt.wantDetails, validationErr.Details)
+				}
+			case err != nil:
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/redis"
+	"sync"
+	"time"
+)
+
+// Rejection est retournée lorsque le service users refuse l'authentification,
+// Status reprend le code HTTP de sa réponse
+type Rejection struct {
+	Status int
+}
+
+func (e *Rejection) Error() string {
+	return fmt.Sprintf("authentication rejected with status code %d", e.Status)
+}
+
+// Authenticator vérifie les headers Authorization auprès du service users. Les utilisateurs
+// authentifiés sont conservés pendant AUTH_CACHE_TTL, sauf invalidation publiée dans Redis.
+// En mode local, les JWT sont vérifiés avec les clés publiques du JWKS sans appeler le service users.
+type Authenticator struct {
+	log    *slog.Logger
+	config *config.Config
+	client *http.Client
+	redis  *redis.Redis
+	keys   *KeySet
+
+	mu       sync.Mutex
+	sessions *helpers.TTLMap[string, *dto.PartialUserDTO]
+	byUser   map[int64]map[string]struct{}
+	// invalidatedAt conserve la date de la dernière invalidation de chaque utilisateur, les tokens
+	// vérifiés localement émis avant cette date sont refusés
+	invalidatedAt *helpers.TTLMap[int64, time.Time]
+	// generation est incrémentée à chaque invalidation, une vérification commencée
+	// avant une invalidation n'est alors pas mise en cache
+	generation uint64
+}
+
+func NewAuthenticator(config *config.Config, redis *redis.Redis, log *slog.Logger) *Authenticator {
+	a := &Authenticator{
+		log:           log,
+		config:        config,
+		client:        &http.Client{Timeout: config.UsersTimeout},
+		redis:         redis,
+		byUser:        make(map[int64]map[string]struct{}),
+		invalidatedAt: helpers.NewTTLMap[int64, time.Time](0, nil),
+	}
+	a.sessions = helpers.NewTTLMap(0, func(key string, user *dto.PartialUserDTO) {
+		a.forget(key, user.ID)
+	})
+	return a
+}
+
+// Run charge les clés publiques en mode local et écoute les invalidations publiées par
+// le service users lors d'une déconnexion ou d'un changement de rôle
+func (a *Authenticator) Run(ctx context.Context) error {
+	switch a.config.AuthMode {
+	case config.AuthModeRemote:
+	case config.AuthModeLocal:
+		if a.config.AuthJwksUrl == "" && a.config.AuthJwksFile == "" {
+			return errors.New("AUTH_JWKS_URL or AUTH_JWKS_FILE is required in local auth mode")
+		}
+		a.keys = NewKeySet(a.config.AuthJwksUrl, a.config.AuthJwksFile, a.config.UsersTimeout, a.log)
+		if err := a.keys.Load(ctx); err != nil {
+			if !a.config.AuthRemoteFallback {
+				return fmt.Errorf("failed to load JWKS: %w", err)
+			}
+			a.log.Error("failed to load JWKS, tokens will be checked by the users service until keys are available", "error", err)
+		}
+		a.keys.Run(ctx, a.config.AuthJwksRefresh)
+	default:
+		return fmt.Errorf("unknown auth mode %q", a.config.AuthMode)
+	}
+
+	messages := a.redis.Subscribe(ctx, a.config.AuthInvalidationChannel)
+	go func() {
+		for msg := range messages {
+			var message redis.AuthInvalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				a.log.Error("failed to decode auth invalidation message", "error", err)
+				continue
+			}
+			a.Invalidate(message.UserID)
+		}
+		a.log.Info("stopping auth invalidation listener")
+	}()
+	return nil
+}
+
+// Authenticate retourne l'utilisateur associé au header Authorization
+func (a *Authenticator) Authenticate(ctx context.Context, authHeader string) (*dto.PartialUserDTO, error) {
+	if a.keys != nil {
+		user, err := a.verifyLocal(ctx, authHeader)
+		if err == nil || !errors.Is(err, errUnverifiable) {
+			return user, err
+		}
+		if !a.config.AuthRemoteFallback {
+			a.log.Warn("token cannot be verified locally", "error", err)
+			return nil, &Rejection{Status: http.StatusUnauthorized}
+		}
+		a.log.Warn("token cannot be verified locally, falling back to users service", "error", err)
+	}
+
+	key := hashHeader(authHeader)
+
+	a.mu.Lock()
+	cached, ok := a.sessions.Get(key)
+	generation := a.generation
+	a.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	user, err := a.checkAuth(ctx, authHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	a.store(key, user, generation)
+	return user, nil
+}
+
+// Invalidate retire du cache toutes les sessions de l'utilisateur et révoque les tokens qu'il a obtenus
+// jusqu'ici. L'invalidation est conservée AUTH_JWT_MAX_LIFETIME, durée au-delà de laquelle ces tokens ont expiré.
+func (a *Authenticator) Invalidate(userID int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.generation++
+	a.invalidatedAt.Set(userID, time.Now(), a.config.AuthJwtMaxLifetime)
+	for key := range a.byUser[userID] {
+		a.sessions.Delete(key)
+	}
+	delete(a.byUser, userID)
+	a.log.Info("auth cache invalidated", "user", userID)
+}
+
+func (a *Authenticator) store(key string, user *dto.PartialUserDTO, generation uint64) {
+	if a.config.AuthCacheTTL <= 0 {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if generation != a.generation {
+		return
+	}
+
+	a.sessions.Set(key, user, a.config.AuthCacheTTL)
+	if a.byUser[user.ID] == nil {
+		a.byUser[user.ID] = make(map[string]struct{})
+	}
+	a.byUser[user.ID][key] = struct{}{}
+}
+
+// forget retire une session expirée de l'index par utilisateur
+func (a *Authenticator) forget(key string, userID int64) {
+	delete(a.byUser[userID], key)
+	if len(a.byUser[userID]) == 0 {
+		delete(a.byUser, userID)
+	}
+}
+
+// checkAuth vérifie le header Authorization auprès du service users
+func (a *Authenticator) checkAuth(ctx context.Context, authHeader string) (*dto.PartialUserDTO, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/internal/users/check-auth", config.UsersBaseUrl), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth check request: %w", err)
+	}
+	req.Header.Set("Authorization", authHeader)
+
+	res, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check auth: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			a.log.Warn("failed to close response body", "error", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, &Rejection{Status: res.StatusCode}
+	}
+
+	var user dto.PartialUserDTO
+	if err := json.NewDecoder(res.Body).Decode(&user); err != nil {
+		return nil, fmt.Errorf("failed to decode user from auth response: %w", err)
+	}
+
+	return &user, nil
+}
+
+// hashHeader évite de conserver les tokens en clair dans le cache
+func hashHeader(authHeader string) string {
+	sum := sha256.Sum256([]byte(authHeader))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,301 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// minRefreshInterval limite les rechargements déclenchés par des tokens signés avec une clé inconnue
+const minRefreshInterval = time.Minute
+
+var errUnknownKey = errors.New("no public key found for token")
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// KeySet contient les clés publiques de signature des tokens. Les clés sont lues depuis un fichier
+// JWKS, ou téléchargées depuis une URL puis enregistrées dans ce fichier pour être disponibles au
+// prochain démarrage même si l'URL ne répond pas.
+type KeySet struct {
+	log    *slog.Logger
+	url    string
+	file   string
+	client *http.Client
+
+	mu          sync.RWMutex
+	keys        map[string]crypto.PublicKey
+	refreshedAt time.Time
+}
+
+func NewKeySet(url string, file string, timeout time.Duration, log *slog.Logger) *KeySet {
+	return &KeySet{
+		log:    log,
+		url:    url,
+		file:   file,
+		client: &http.Client{Timeout: timeout},
+		keys:   make(map[string]crypto.PublicKey),
+	}
+}
+
+// Load charge les clés depuis le fichier, puis depuis l'URL si elle est configurée
+func (k *KeySet) Load(ctx context.Context) error {
+	var fileErr error
+	if k.file != "" {
+		if fileErr = k.loadFile(); fileErr != nil && k.url == "" {
+			return fileErr
+		}
+	}
+	if k.url == "" {
+		return nil
+	}
+
+	if err := k.refresh(ctx); err != nil {
+		if fileErr == nil && k.file != "" {
+			k.log.Warn("failed to download JWKS, using keys cached on disk", "error", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// Run recharge régulièrement les clés depuis l'URL
+func (k *KeySet) Run(ctx context.Context, interval time.Duration) {
+	if k.url == "" || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := k.refresh(ctx); err != nil {
+					k.log.Error("failed to refresh JWKS", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// Key retourne la clé publique correspondant au kid. Un kid inconnu déclenche un rechargement
+// depuis l'URL, au plus une fois par minRefreshInterval, pour prendre en compte une rotation des clés.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	if key, ok := k.lookup(kid); ok {
+		return key, nil
+	}
+
+	k.mu.RLock()
+	canRefresh := k.url != "" && time.Since(k.refreshedAt) > minRefreshInterval
+	k.mu.RUnlock()
+	if canRefresh {
+		if err := k.refresh(ctx); err != nil {
+			k.log.Error("failed to refresh JWKS", "error", err)
+		}
+		if key, ok := k.lookup(kid); ok {
+			return key, nil
+		}
+	}
+
+	return nil, errUnknownKey
+}
+
+// lookup cherche la clé du kid, ou l'unique clé du jeu si le token n'a pas de kid
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *KeySet) loadFile() error {
+	content, err := os.ReadFile(k.file)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS file: %w", err)
+	}
+	return k.set(content)
+}
+
+func (k *KeySet) refresh(ctx context.Context) error {
+	k.mu.Lock()
+	k.refreshedAt = time.Now()
+	k.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to download JWKS: %w", err)
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			k.log.Warn("failed to close response body", "error", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d returned by JWKS url", res.StatusCode)
+	}
+
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	if err := k.set(content); err != nil {
+		return err
+	}
+
+	if k.file != "" {
+		if err := writeFileAtomic(k.file, content); err != nil {
+			k.log.Warn("failed to cache JWKS on disk", "error", err)
+		}
+	}
+	return nil
+}
+
+func (k *KeySet) set(content []byte) error {
+	var set jwks
+	if err := json.Unmarshal(content, &set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+		publicKey, err := key.publicKey()
+		if err != nil {
+			k.log.Warn("ignoring invalid key from JWKS", "kid", key.Kid, "error", err)
+			continue
+		}
+		keys[key.Kid] = publicKey
+	}
+	if len(keys) == 0 {
+		return errors.New("JWKS does not contain any usable signing key")
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+func (key *jwk) publicKey() (crypto.PublicKey, error) {
+	switch key.Kty {
+	case "RSA":
+		n, err := decodeBigInt(key.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(key.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("invalid RSA exponent")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch key.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+		}
+		x, err := decodeBigInt(key.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(key.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if key.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", key.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", key.Kty)
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+// writeFileAtomic évite de laisser un fichier tronqué si le service s'arrête pendant l'écriture
+func writeFileAtomic(path string, content []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
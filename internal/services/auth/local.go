@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"net/http"
+	"strconv"
+	"strings"
+	"supmap-users/internal/models/dto"
+	"time"
+)
+
+// clockSkew tolère un léger décalage d'horloge avec le service users qui émet les tokens
+const clockSkew = 30 * time.Second
+
+// signingMethods sont les algorithmes acceptés, les tokens non signés ou signés avec
+// un secret partagé (HS256) sont refusés
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+
+// errUnverifiable indique que le token n'a pas pu être vérifié localement, sans qu'il
+// soit pour autant invalide : clé de signature inconnue ou claims incomplets
+var errUnverifiable = errors.New("token cannot be verified locally")
+
+type userClaims struct {
+	jwt.RegisteredClaims
+	UserID *int64          `json:"id"`
+	Handle string          `json:"handle"`
+	Role   json.RawMessage `json:"role"`
+}
+
+// verifyLocal vérifie la signature et la validité du token avec les clés du JWKS, puis construit
+// l'utilisateur à partir des claims id (ou sub), handle et role
+func (a *Authenticator) verifyLocal(ctx context.Context, authHeader string) (*dto.PartialUserDTO, error) {
+	scheme, token, found := strings.Cut(authHeader, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil, &Rejection{Status: http.StatusUnauthorized}
+	}
+
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+	}
+	if a.config.AuthJwtIssuer != "" {
+		options = append(options, jwt.WithIssuer(a.config.AuthJwtIssuer))
+	}
+	if a.config.AuthJwtAudience != "" {
+		options = append(options, jwt.WithAudience(a.config.AuthJwtAudience))
+	}
+
+	var claims userClaims
+	_, err := jwt.NewParser(options...).ParseWithClaims(strings.TrimSpace(token), &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	switch {
+	case errors.Is(err, errUnknownKey):
+		return nil, fmt.Errorf("%w: %w", errUnverifiable, err)
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return nil, &Rejection{Status: http.StatusForbidden}
+	case err != nil:
+		a.log.Debug("invalid token", "error", err)
+		return nil, &Rejection{Status: http.StatusUnauthorized}
+	}
+
+	user, err := claims.toUser()
+	if err != nil {
+		return nil, err
+	}
+
+	if a.revoked(user.ID, claims.IssuedAt) {
+		a.log.Debug("token issued before the last invalidation", "user", user.ID)
+		return nil, &Rejection{Status: http.StatusUnauthorized}
+	}
+
+	return user, nil
+}
+
+// revoked indique si le token a été émis avant la dernière invalidation de l'utilisateur.
+// Le claim iat étant exprimé en secondes, un token émis dans la seconde de l'invalidation est accepté,
+// et un token sans iat est refusé dès que l'utilisateur a été invalidé.
+func (a *Authenticator) revoked(userID int64, issuedAt *jwt.NumericDate) bool {
+	a.mu.Lock()
+	invalidatedAt, ok := a.invalidatedAt.Get(userID)
+	a.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return issuedAt == nil || issuedAt.Before(invalidatedAt.Truncate(time.Second))
+}
+
+func (c *userClaims) toUser() (*dto.PartialUserDTO, error) {
+	user := &dto.PartialUserDTO{Handle: c.Handle}
+
+	if c.UserID != nil {
+		user.ID = *c.UserID
+	} else if id, err := strconv.ParseInt(c.Subject, 10, 64); err == nil {
+		user.ID = id
+	} else {
+		return nil, fmt.Errorf("%w: missing user id claim", errUnverifiable)
+	}
+
+	// Le rôle est accepté sous forme de chaîne ("ROLE_ADMIN") ou d'objet ({"name": "ROLE_ADMIN"})
+	if len(c.Role) > 0 && string(c.Role) != "null" {
+		var name string
+		if err := json.Unmarshal(c.Role, &name); err != nil {
+			var role dto.RoleDTO
+			if err := json.Unmarshal(c.Role, &role); err != nil {
+				return nil, fmt.Errorf("%w: invalid role claim", errUnverifiable)
+			}
+			name = role.Name
+		}
+		user.Role = &dto.RoleDTO{Name: name}
+	}
+
+	return user, nil
+}
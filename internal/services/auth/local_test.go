@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"supmap-users/internal/config"
+	"supmap-users/internal/models/dto"
+	"testing"
+	"time"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testKeys{rsa: rsaKey, ec: ecKey}
+}
+
+// jwks retourne le JWKS des clés publiques, la clé RSA sous le kid "rsa" et la clé EC sous le kid "ec"
+func (k *testKeys) jwks() []byte {
+	encode := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+	content, _ := json.Marshal(jwks{Keys: []jwk{
+		{Kid: "rsa", Kty: "RSA", Use: "sig", N: encode(k.rsa.N), E: encode(big.NewInt(int64(k.rsa.E)))},
+		{Kid: "ec", Kty: "EC", Use: "sig", Crv: "P-256", X: encode(k.ec.X), Y: encode(k.ec.Y)},
+	}})
+	return content
+}
+
+func newTestAuthenticator(t *testing.T, keys *testKeys, conf *config.Config) *Authenticator {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	conf.AuthJwtMaxLifetime = time.Hour
+
+	a := NewAuthenticator(conf, nil, log)
+	a.keys = NewKeySet("", "", time.Second, log)
+	if err := a.keys.set(keys.jwks()); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key any, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "Bearer " + signed
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"id":     42,
+		"handle": "john",
+		"role":   "ROLE_USER",
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Hour).Unix(),
+	}
+}
+
+func with(claims jwt.MapClaims, key string, value any) jwt.MapClaims {
+	if value == nil {
+		delete(claims, key)
+	} else {
+		claims[key] = value
+	}
+	return claims
+}
+
+func TestVerifyLocal(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestAuthenticator(t, keys, &config.Config{})
+
+	tests := []struct {
+		name         string
+		header       string
+		expectedUser *dto.PartialUserDTO
+		// expectedStatus est le code de la Rejection attendue, 0 si une autre erreur est attendue
+		expectedStatus int
+		unverifiable   bool
+	}{
+		{
+			name:         "valid RSA token",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, validClaims()),
+			expectedUser: &dto.PartialUserDTO{ID: 42, Handle: "john", Role: &dto.RoleDTO{Name: "ROLE_USER"}},
+		},
+		{
+			name:         "valid ECDSA token",
+			header:       sign(t, jwt.SigningMethodES256, "ec", keys.ec, validClaims()),
+			expectedUser: &dto.PartialUserDTO{ID: 42, Handle: "john", Role: &dto.RoleDTO{Name: "ROLE_USER"}},
+		},
+		{
+			name:         "role as an object",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "role", map[string]string{"name": "ROLE_ADMIN"})),
+			expectedUser: &dto.PartialUserDTO{ID: 42, Handle: "john", Role: &dto.RoleDTO{Name: "ROLE_ADMIN"}},
+		},
+		{
+			name:         "user id from sub",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(with(validClaims(), "id", nil), "sub", "7")),
+			expectedUser: &dto.PartialUserDTO{ID: 7, Handle: "john", Role: &dto.RoleDTO{Name: "ROLE_USER"}},
+		},
+		{
+			name:         "without role",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "role", nil)),
+			expectedUser: &dto.PartialUserDTO{ID: 42, Handle: "john"},
+		},
+		{
+			name:           "missing bearer scheme",
+			header:         "Basic dXNlcjpwYXNz",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "malformed token",
+			header:         "Bearer not.a.token",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "expired token",
+			header:         sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix())),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "missing expiration",
+			header:         sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "exp", nil)),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "ECDSA signature with an RSA key id",
+			header:         sign(t, jwt.SigningMethodES256, "rsa", keys.ec, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "RSA signature with an ECDSA key id",
+			header:         sign(t, jwt.SigningMethodRS256, "ec", keys.rsa, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "shared secret signature",
+			header:         sign(t, jwt.SigningMethodHS256, "rsa", []byte("secret"), validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "unsigned token",
+			header:         sign(t, jwt.SigningMethodNone, "rsa", jwt.UnsafeAllowNoneSignatureType, validClaims()),
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "unknown key id",
+			header:       sign(t, jwt.SigningMethodRS256, "unknown", keys.rsa, validClaims()),
+			unverifiable: true,
+		},
+		{
+			name:         "missing user id",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "id", nil)),
+			unverifiable: true,
+		},
+		{
+			name:         "non numeric sub",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(with(validClaims(), "id", nil), "sub", "john")),
+			unverifiable: true,
+		},
+		{
+			name:         "invalid role",
+			header:       sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "role", 12)),
+			unverifiable: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := a.verifyLocal(context.Background(), tt.header)
+
+			switch {
+			case tt.expectedUser != nil:
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				assertUser(t, user, tt.expectedUser)
+			case tt.unverifiable:
+				if !errors.Is(err, errUnverifiable) {
+					t.Errorf("expected an unverifiable token, got %v", err)
+				}
+			default:
+				var rejection *Rejection
+				if !errors.As(err, &rejection) || rejection.Status != tt.expectedStatus {
+					t.Errorf("expected a rejection with status %d, got %v", tt.expectedStatus, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyLocalInvalidation(t *testing.T) {
+	keys := newTestKeys(t)
+	a := newTestAuthenticator(t, keys, &config.Config{})
+
+	issuedBefore := sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "iat", time.Now().Add(-time.Minute).Unix()))
+	withoutIssuedAt := sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "iat", nil))
+	otherUser := sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(with(validClaims(), "id", 43), "iat", time.Now().Add(-time.Minute).Unix()))
+
+	if _, err := a.verifyLocal(context.Background(), withoutIssuedAt); err != nil {
+		t.Fatalf("expected a token without iat to be accepted before any invalidation, got %v", err)
+	}
+
+	a.Invalidate(42)
+
+	for name, header := range map[string]string{"issued before": issuedBefore, "without iat": withoutIssuedAt} {
+		var rejection *Rejection
+		if _, err := a.verifyLocal(context.Background(), header); !errors.As(err, &rejection) || rejection.Status != http.StatusUnauthorized {
+			t.Errorf("%s: expected a 401 rejection, got %v", name, err)
+		}
+	}
+
+	issuedAfter := sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "iat", time.Now().Add(time.Second).Unix()))
+	if _, err := a.verifyLocal(context.Background(), issuedAfter); err != nil {
+		t.Errorf("expected a token issued after the invalidation to be accepted, got %v", err)
+	}
+	if _, err := a.verifyLocal(context.Background(), otherUser); err != nil {
+		t.Errorf("expected tokens of other users to be accepted, got %v", err)
+	}
+}
+
+func TestAuthenticateRemoteFallback(t *testing.T) {
+	keys := newTestKeys(t)
+	unknownKey := sign(t, jwt.SigningMethodRS256, "unknown", keys.rsa, validClaims())
+
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/internal/users/check-auth" || r.Header.Get("Authorization") != unknownKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = fmt.Fprint(w, `{"id": 42, "handle": "john", "role": {"name": "ROLE_USER"}}`)
+	}))
+	defer server.Close()
+
+	previous := config.UsersBaseUrl
+	config.UsersBaseUrl = server.URL
+	defer func() { config.UsersBaseUrl = previous }()
+
+	t.Run("without fallback", func(t *testing.T) {
+		a := newTestAuthenticator(t, keys, &config.Config{})
+
+		var rejection *Rejection
+		if _, err := a.Authenticate(context.Background(), unknownKey); !errors.As(err, &rejection) || rejection.Status != http.StatusUnauthorized {
+			t.Errorf("expected a 401 rejection, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no call to the users service, got %d", calls)
+		}
+	})
+
+	t.Run("with fallback", func(t *testing.T) {
+		a := newTestAuthenticator(t, keys, &config.Config{AuthRemoteFallback: true, AuthCacheTTL: time.Minute})
+
+		user, err := a.Authenticate(context.Background(), unknownKey)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		assertUser(t, user, &dto.PartialUserDTO{ID: 42, Handle: "john", Role: &dto.RoleDTO{Name: "ROLE_USER"}})
+
+		// La réponse du service users est conservée en cache
+		if _, err := a.Authenticate(context.Background(), unknownKey); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("expected a single call to the users service, got %d", calls)
+		}
+	})
+
+	t.Run("invalid tokens are not sent to the users service", func(t *testing.T) {
+		a := newTestAuthenticator(t, keys, &config.Config{AuthRemoteFallback: true})
+		calls = 0
+
+		expired := sign(t, jwt.SigningMethodRS256, "rsa", keys.rsa, with(validClaims(), "exp", time.Now().Add(-time.Hour).Unix()))
+		var rejection *Rejection
+		if _, err := a.Authenticate(context.Background(), expired); !errors.As(err, &rejection) || rejection.Status != http.StatusForbidden {
+			t.Errorf("expected a 403 rejection, got %v", err)
+		}
+		if calls != 0 {
+			t.Errorf("expected no call to the users service, got %d", calls)
+		}
+	})
+}
+
+func assertUser(t *testing.T, user *dto.PartialUserDTO, expected *dto.PartialUserDTO) {
+	t.Helper()
+	if user == nil || user.ID != expected.ID || user.Handle != expected.Handle {
+		t.Fatalf("expected %+v, got %+v", expected, user)
+	}
+	if (user.Role == nil) != (expected.Role == nil) || (user.Role != nil && user.Role.Name != expected.Role.Name) {
+		t.Errorf("expected role %+v, got %+v", expected.Role, user.Role)
+	}
+}
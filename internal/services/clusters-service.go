@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+)
+
+// maxZoom est le niveau de zoom maximal des cartes web (MapLibre, Leaflet)
+const maxZoom = 22
+
+// IncidentClusters contient les clusters d'une zone, ou ses incidents si le zoom
+// est supérieur à CLUSTERS_MAX_ZOOM
+type IncidentClusters struct {
+	Clusters  []models.Cluster
+	Incidents []models.Incident
+	Truncated bool
+}
+
+// GetIncidentClusters godoc
+// Regroupe les incidents actifs de la zone par cellule geohash, dont la taille dépend du zoom.
+// Au-delà de CLUSTERS_MAX_ZOOM, les incidents sont retournés individuellement comme par FindIncidentsInBoundingBox.
+func (s *Service) GetIncidentClusters(ctx context.Context, typeId *int64, box *helpers.BoundingBox, zoom int) (*IncidentClusters, error) {
+	if zoom < 0 || zoom > maxZoom {
+		return nil, &ErrorWithCode{
+			Message: "zoom must be between 0 and 22",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if zoom > s.config.ClustersMaxZoom {
+		incidents, truncated, err := s.FindIncidentsInBoundingBox(ctx, typeId, box)
+		if err != nil {
+			return nil, err
+		}
+		return &IncidentClusters{Clusters: []models.Cluster{}, Incidents: incidents, Truncated: truncated}, nil
+	}
+
+	if err := box.Validate(); err != nil {
+		return nil, &ErrorWithCode{
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if typeId != nil {
+		incidentType, err := s.incidents.FindIncidentTypeById(ctx, typeId)
+		if err != nil {
+			return nil, err
+		}
+
+		if incidentType == nil {
+			return nil, &ErrorWithCode{
+				Message: "Incident type does not exists",
+				Code:    http.StatusNotFound,
+			}
+		}
+	}
+
+	cells, err := s.incidents.FindIncidentCells(ctx, box, typeId, clusterPrecision(zoom))
+	if err != nil {
+		return nil, err
+	}
+
+	types, err := s.findClusterTypes(ctx, cells)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IncidentClusters{Clusters: mergeCells(cells, types)}, nil
+}
+
+// clusterPrecision choisit la longueur des geohash de sorte qu'une tuile de carte contienne
+// entre 2 et 8 cellules en largeur : au zoom z, une tuile couvre 360/2^z degrés de longitude
+// et un geohash de n caractères découpe la longitude en 5n/2 bits
+func clusterPrecision(zoom int) int {
+	precision := (2*(zoom+2) + 2) / 5
+	return max(1, min(helpers.GeohashPrecision, precision))
+}
+
+// findClusterTypes récupère les types des cellules, y compris les types désactivés dont des incidents sont encore en cours
+func (s *Service) findClusterTypes(ctx context.Context, cells []models.IncidentCell) (map[int64]*models.Type, error) {
+	all, err := s.incidents.FindAllIncidentTypes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	types := make(map[int64]*models.Type, len(all))
+	for i := range all {
+		types[all[i].ID] = &all[i]
+	}
+
+	for _, cell := range cells {
+		if _, ok := types[cell.TypeID]; ok {
+			continue
+		}
+		t, err := s.incidents.FindIncidentTypeById(ctx, &cell.TypeID)
+		if err != nil {
+			return nil, err
+		}
+		types[cell.TypeID] = t
+	}
+
+	return types, nil
+}
+
+// mergeCells regroupe les cellules triées par geohash puis par type en un cluster par geohash
+func mergeCells(cells []models.IncidentCell, types map[int64]*models.Type) []models.Cluster {
+	clusters := []models.Cluster{}
+
+	var sumLat, sumLon float64
+	for i, cell := range cells {
+		if i == 0 || cells[i-1].Geohash != cell.Geohash {
+			clusters = append(clusters, models.Cluster{
+				Geohash: cell.Geohash,
+				Bounds:  helpers.BoundingBox{MinLat: cell.MinLat, MinLon: cell.MinLon, MaxLat: cell.MaxLat, MaxLon: cell.MaxLon},
+			})
+			sumLat, sumLon = 0, 0
+		}
+
+		cluster := &clusters[len(clusters)-1]
+		cluster.Count += cell.Count
+		cluster.Bounds.MinLat = min(cluster.Bounds.MinLat, cell.MinLat)
+		cluster.Bounds.MinLon = min(cluster.Bounds.MinLon, cell.MinLon)
+		cluster.Bounds.MaxLat = max(cluster.Bounds.MaxLat, cell.MaxLat)
+		cluster.Bounds.MaxLon = max(cluster.Bounds.MaxLon, cell.MaxLon)
+		cluster.Types = append(cluster.Types, models.TypeCount{Type: types[cell.TypeID], Count: cell.Count})
+
+		// Barycentre des incidents de la cellule
+		sumLat += cell.SumLat
+		sumLon += cell.SumLon
+		cluster.Latitude = sumLat / float64(cluster.Count)
+		cluster.Longitude = sumLon / float64(cluster.Count)
+	}
+
+	for i := range clusters {
+		counts := clusters[i].Types
+		sort.SliceStable(counts, func(a, b int) bool {
+			return counts[a].Count > counts[b].Count
+		})
+	}
+
+	return clusters
+}
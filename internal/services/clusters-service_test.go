@@ -0,0 +1,124 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"testing"
+)
+
+func TestClusterPrecision(t *testing.T) {
+	tests := []struct {
+		name     string
+		zoom     int
+		expected int
+	}{
+		{name: "world", zoom: 0, expected: 1},
+		{name: "continent", zoom: 4, expected: 2},
+		{name: "city", zoom: 10, expected: 5},
+		{name: "default CLUSTERS_MAX_ZOOM", zoom: 14, expected: 6},
+		{name: "after default CLUSTERS_MAX_ZOOM", zoom: 15, expected: 7},
+		{name: "last zoom before the geohash precision", zoom: 19, expected: 8},
+		{name: "clamped to the geohash precision", zoom: 20, expected: 9},
+		{name: "maximum zoom", zoom: 22, expected: helpers.GeohashPrecision},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if precision := clusterPrecision(tt.zoom); precision != tt.expected {
+				t.Errorf("expected %d, got %d", tt.expected, precision)
+			}
+		})
+	}
+}
+
+func TestClusterPrecisionCellsPerTile(t *testing.T) {
+	for zoom := 0; zoom <= maxZoom; zoom++ {
+		precision := clusterPrecision(zoom)
+		if precision == helpers.GeohashPrecision {
+			continue
+		}
+
+		// Un geohash de n caractères découpe la longitude en ceil(5n/2) bits
+		lonBits := (5*precision + 1) / 2
+		cells := math.Pow(2, float64(lonBits-zoom))
+		if cells < 2 || cells > 8 {
+			t.Errorf("zoom %d: expected 2 to 8 cells per tile, got %v", zoom, cells)
+		}
+	}
+}
+
+func TestGetIncidentClustersInvalidZoom(t *testing.T) {
+	s := &Service{}
+	box := &helpers.BoundingBox{MinLat: 0, MinLon: 0, MaxLat: 1, MaxLon: 1}
+
+	for _, zoom := range []int{-1, maxZoom + 1} {
+		_, err := s.GetIncidentClusters(context.Background(), nil, box, zoom)
+		var ewc *ErrorWithCode
+		if !errors.As(err, &ewc) || ewc.Code != http.StatusBadRequest {
+			t.Errorf("zoom %d: expected a 400 error, got %v", zoom, err)
+		}
+	}
+}
+
+func TestMergeCells(t *testing.T) {
+	roadworks := &models.Type{ID: 1, Name: "roadworks"}
+	accident := &models.Type{ID: 2, Name: "accident"}
+	jam := &models.Type{ID: 3, Name: "jam"}
+	types := map[int64]*models.Type{1: roadworks, 2: accident, 3: jam}
+
+	cells := []models.IncidentCell{
+		{Geohash: "u09t", TypeID: 1, Count: 1, SumLat: 48.8, SumLon: 2.3, MinLat: 48.8, MinLon: 2.3, MaxLat: 48.8, MaxLon: 2.3},
+		{Geohash: "u09t", TypeID: 2, Count: 3, SumLat: 146.7, SumLon: 7.2, MinLat: 48.7, MinLon: 2.2, MaxLat: 49.0, MaxLon: 2.6},
+		{Geohash: "u09t", TypeID: 3, Count: 2, SumLat: 97.8, SumLon: 4.8, MinLat: 48.85, MinLon: 2.35, MaxLat: 48.95, MaxLon: 2.45},
+		{Geohash: "u09w", TypeID: 3, Count: 2, SumLat: 98.0, SumLon: 5.0, MinLat: 48.9, MinLon: 2.4, MaxLat: 49.1, MaxLon: 2.6},
+		{Geohash: "u09y", TypeID: 4, Count: 1, SumLat: 49.2, SumLon: 2.9, MinLat: 49.2, MinLon: 2.9, MaxLat: 49.2, MaxLon: 2.9},
+	}
+
+	clusters := mergeCells(cells, types)
+	if len(clusters) != 3 {
+		t.Fatalf("expected 3 clusters, got %d", len(clusters))
+	}
+
+	first := clusters[0]
+	if first.Geohash != "u09t" || first.Count != 6 {
+		t.Errorf("expected 6 incidents in u09t, got %d in %s", first.Count, first.Geohash)
+	}
+	if math.Abs(first.Latitude-(48.8+146.7+97.8)/6) > 1e-9 || math.Abs(first.Longitude-(2.3+7.2+4.8)/6) > 1e-9 {
+		t.Errorf("unexpected barycenter %f, %f", first.Latitude, first.Longitude)
+	}
+	expectedBounds := helpers.BoundingBox{MinLat: 48.7, MinLon: 2.2, MaxLat: 49.0, MaxLon: 2.6}
+	if first.Bounds != expectedBounds {
+		t.Errorf("expected bounds %v, got %v", expectedBounds, first.Bounds)
+	}
+
+	// Les types sont triés du plus au moins représenté
+	expectedTypes := []models.TypeCount{{Type: accident, Count: 3}, {Type: jam, Count: 2}, {Type: roadworks, Count: 1}}
+	if len(first.Types) != len(expectedTypes) {
+		t.Fatalf("expected %d types, got %d", len(expectedTypes), len(first.Types))
+	}
+	for i, expected := range expectedTypes {
+		if first.Types[i] != expected {
+			t.Errorf("type %d: expected %v, got %v", i, expected, first.Types[i])
+		}
+	}
+
+	second := clusters[1]
+	if second.Geohash != "u09w" || second.Count != 2 || len(second.Types) != 1 || second.Latitude != 49.0 || second.Longitude != 2.5 {
+		t.Errorf("unexpected cluster %+v", second)
+	}
+
+	// Un type introuvable est conservé sans détail
+	if third := clusters[2]; len(third.Types) != 1 || third.Types[0].Type != nil || third.Types[0].Count != 1 {
+		t.Errorf("unexpected cluster %+v", third)
+	}
+}
+
+func TestMergeCellsEmpty(t *testing.T) {
+	if clusters := mergeCells(nil, nil); clusters == nil || len(clusters) != 0 {
+		t.Errorf("expected an empty, non nil slice, got %#v", clusters)
+	}
+}
@@ -3,15 +3,20 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
 	"sort"
+	"strings"
 	"supmap-users/internal/api/validations"
 	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
 	"supmap-users/internal/models"
 	"supmap-users/internal/models/dto"
 	"supmap-users/internal/repository"
+	"supmap-users/internal/services/photos"
 	"supmap-users/internal/services/redis"
+	"supmap-users/internal/services/storage"
 	"time"
 )
 
@@ -21,15 +26,21 @@ type Service struct {
 	incidents    *repository.Incidents
 	interactions *repository.Interactions
 	redis        *redis.Redis
+	profanity    *helpers.ProfanityFilter
+	storage      storage.Storage
+	schemas      *attributesSchemas
 }
 
-func NewService(log *slog.Logger, config *config.Config, incidents *repository.Incidents, interactions *repository.Interactions, redis *redis.Redis) *Service {
+func NewService(log *slog.Logger, config *config.Config, incidents *repository.Incidents, interactions *repository.Interactions, redis *redis.Redis, profanity *helpers.ProfanityFilter, storage storage.Storage) *Service {
 	return &Service{
 		log:          log,
 		config:       config,
 		incidents:    incidents,
 		interactions: interactions,
 		redis:        redis,
+		profanity:    profanity,
+		storage:      storage,
+		schemas:      newAttributesSchemas(),
 	}
 }
 
@@ -91,7 +102,23 @@ func (s *Service) FindTypeById(ctx context.Context, id int64) (*models.Type, err
 	return t, err
 }
 
-func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO, body *validations.CreateIncidentValidator) (*models.Incident, error) {
+func (s *Service) FindIncidentById(ctx context.Context, id int64) (*models.Incident, error) {
+	incident, err := s.incidents.FindIncidentById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident == nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	return incident, nil
+}
+
+func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO, body *validations.CreateIncidentValidator, uploads [][]byte) (*models.Incident, error) {
 
 	// Check si le type existe
 	incidentType, err := s.incidents.FindIncidentTypeById(ctx, &body.TypeId)
@@ -106,6 +133,17 @@ func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO,
 		}
 	}
 
+	if incidentType.DisabledAt != nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident type is disabled",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if err := s.checkAttributes(incidentType, body.Attributes); err != nil {
+		return nil, err
+	}
+
 	// Check le dernier report de l'utilisateur (un signalement par minute)
 	last, err := s.incidents.GetLastUserIncident(ctx, user)
 	if err != nil {
@@ -121,13 +159,30 @@ func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO,
 		}
 	}
 
-	// Si un ou plusieurs incidents existent déjà dans un rayon de 100m :
+	prepared, err := s.preparePhotos(uploads)
+	if err != nil {
+		return nil, err
+	}
+
+	// Si un ou plusieurs incidents existent déjà dans un rayon de 100m dans le même sens de circulation :
 	// Le signalement n’en crée pas un nouveau, mais devient une interaction attachée à un incident existant.
 	// Si plusieurs incidents existent :
 	// On choisit celui avec le plus d’interactions.
 	// S’il y a égalité : on prend le plus proche.
 	// En cas d’égalité parfaite : on choisit arbitrairement (ex. premier de la liste).
-	incidents, err := s.incidents.FindIncidentsInZone(ctx, body.Latitude, body.Longitude, 100, &body.TypeId)
+	nearby, err := s.incidents.FindIncidentsInZone(ctx, body.Latitude, body.Longitude, 100, &body.TypeId)
+	if err != nil {
+		return nil, err
+	}
+
+	// Un accident sur une chaussée n'est pas rattaché à un signalement de la chaussée opposée
+	var incidents []models.IncidentWithDistance
+	for _, candidate := range nearby {
+		if candidate.SameDirection(body.Heading, body.BothDirections, s.config.HeadingTolerance) {
+			incidents = append(incidents, candidate)
+		}
+	}
+
 	if len(incidents) > 0 {
 		// Choisir l'évènement à intéragir
 		sort.SliceStable(incidents, func(i, j int) bool {
@@ -145,12 +200,16 @@ func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO,
 			return nil, err
 		}
 
+		// La gravité signalée fait évoluer d'un niveau celle de l'incident existant
 		newInteraction := &validations.CreateInteractionValidator{
 			IncidentID:     chosen.ID,
 			IsStillPresent: toPtr(true),
 		}
+		if body.Severity != nil {
+			newInteraction.SeverityChange = chosen.SeverityChangeTowards(*body.Severity)
+		}
 
-		if _, err := s.CreateInteraction(ctx, user, newInteraction); err != nil {
+		if _, err := s.createInteraction(ctx, user, newInteraction, prepared); err != nil {
 			return nil, err
 		}
 
@@ -163,16 +222,27 @@ func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO,
 		}
 	}
 
+	severity := models.SeverityModerate
+	if body.Severity != nil {
+		severity = *body.Severity
+	}
+
 	// Insérer l'incident
 	incident := &models.Incident{
-		TypeID:    incidentType.ID,
-		UserID:    user.ID,
-		Latitude:  *body.Latitude,
-		Longitude: *body.Longitude,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-	}
-	if err = s.incidents.CreateIncident(ctx, incident); err != nil {
+		TypeID:         incidentType.ID,
+		UserID:         user.ID,
+		Latitude:       *body.Latitude,
+		Longitude:      *body.Longitude,
+		Geohash:        helpers.EncodeGeohash(*body.Latitude, *body.Longitude, helpers.GeohashPrecision),
+		Heading:        body.Heading,
+		BothDirections: body.BothDirections,
+		Severity:       severity,
+		Description:    s.cleanDescription(body.Description),
+		Attributes:     body.Attributes,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if err = s.insertIncident(ctx, incident, prepared); err != nil {
 		return nil, err
 	}
 
@@ -192,39 +262,342 @@ func (s *Service) CreateIncident(ctx context.Context, user *dto.PartialUserDTO,
 	return inserted, nil
 }
 
-func (s *Service) FindIncidentsInRadius(ctx context.Context, typeId *int64, lat, lon float64, radius int64) ([]models.IncidentWithDistance, error) {
-	incidentType, err := s.incidents.FindIncidentTypeById(ctx, typeId)
+// insertIncident enregistre l'incident et ses photos dans une même transaction. Les fichiers des photos
+// sont enregistrés avant la transaction et supprimés si elle est annulée.
+func (s *Service) insertIncident(ctx context.Context, incident *models.Incident, prepared []*photos.Processed) (err error) {
+	stored, err := s.storePhotos(ctx, prepared, incident.UserID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		s.deletePhotoBlobs(ctx, stored)
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			s.deletePhotoBlobs(ctx, stored)
+		}
+	}()
+
+	if err = s.incidents.CreateIncidentTx(ctx, tx, incident); err != nil {
+		return err
+	}
+
+	for i := range stored {
+		stored[i].IncidentID = incident.ID
+	}
+	if err = s.incidents.InsertPhotosTx(ctx, tx, stored); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdateIncidentDescription godoc
+// Modifie la description d'un incident en cours. Seul l'auteur du signalement peut la modifier,
+// pendant DESCRIPTION_EDIT_WINDOW après la création de l'incident. La modification met à jour
+// updated_at pour la synchronisation, ce qui repousse aussi l'expiration de l'incident sans
+// confirmation : la fenêtre de modification limite ce report à DESCRIPTION_EDIT_WINDOW.
+func (s *Service) UpdateIncidentDescription(ctx context.Context, user *dto.PartialUserDTO, id int64, body *validations.UpdateIncidentDescriptionValidator) (incident *models.Incident, err error) {
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	incident, err = s.incidents.FindIncidentByIdTx(ctx, tx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	if incidentType == nil {
+	if incident == nil {
 		return nil, &ErrorWithCode{
-			Message: "Incident type does not exists",
+			Message: "Incident does not exists",
 			Code:    http.StatusNotFound,
 		}
 	}
 
-	incidents, err := s.incidents.FindIncidentsInZone(ctx, &lat, &lon, radius, typeId)
-	if err != nil {
+	if incident.UserID != user.ID {
+		return nil, &ErrorWithCode{
+			Message: "Only the reporter can edit the description of this incident",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	if incident.DeletedAt != nil {
+		return nil, &ErrorWithCode{
+			Message: "This incident is locked",
+			Code:    http.StatusLocked,
+		}
+	}
+
+	if time.Since(incident.CreatedAt) > s.config.DescriptionEditWindow {
+		return nil, &ErrorWithCode{
+			Message: "The description of this incident can no longer be edited",
+			Code:    http.StatusForbidden,
+		}
+	}
+
+	incident.Description = s.cleanDescription(body.Description)
+	if err = s.incidents.UpdateIncidentDescriptionTx(ctx, tx, incident); err != nil {
+		return nil, err
+	}
+
+	// Le message n'est publié qu'une fois la modification validée en base
+	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
 
-	for i, incident := range incidents {
-		completed, err := s.incidents.FindIncidentById(ctx, incident.ID)
+	if err := s.redis.PublishMessage(s.config.IncidentChannel, &redis.IncidentMessage{
+		Data:   *dto.IncidentToRedis(incident),
+		Action: redis.Updated,
+	}); err != nil {
+		s.log.Error("failed to publish incident update", "id", incident.ID, "error", err)
+	}
+
+	return incident, nil
+}
+
+// checkAttributes valide les attributs du signalement avec le schéma compilé du type d'incident.
+// Un type sans schéma n'accepte pas d'attributs.
+func (s *Service) checkAttributes(incidentType *models.Type, attributes map[string]any) error {
+	if incidentType.AttributesSchema == nil {
+		if len(attributes) > 0 {
+			return &ErrorWithCode{
+				Message: "This incident type does not accept attributes",
+				Code:    http.StatusBadRequest,
+			}
+		}
+		return nil
+	}
+
+	schema, err := s.schemas.get(incidentType)
+	if err != nil {
+		return fmt.Errorf("invalid attributes schema for incident type %d: %w", incidentType.ID, err)
+	}
+
+	if attributes == nil {
+		attributes = map[string]any{}
+	}
+	if errs := schema.Validate(attributes); len(errs) > 0 {
+		details := make(map[string]string, len(errs))
+		for path, message := range errs {
+			details["attributes"+strings.TrimSuffix(path, "/")] = message
+		}
+		return &validations.ValidationError{Message: "Invalid attributes", Details: details}
+	}
+	return nil
+}
+
+// cleanDescription nettoie la description saisie et masque les mots interdits,
+// une description vide après nettoyage n'est pas conservée
+func (s *Service) cleanDescription(description *string) *string {
+	if description == nil {
+		return nil
+	}
+
+	cleaned := helpers.SanitizeText(*description)
+	if cleaned == "" {
+		return nil
+	}
+	if s.profanity != nil {
+		cleaned = s.profanity.Filter(cleaned)
+	}
+	return &cleaned
+}
+
+// FindIncidentsInRadius godoc
+// Récupère les incidents actifs dans un rayon autour d'un point. Si heading est fourni, seuls les incidents
+// concernant les véhicules circulant à ce cap sont retournés.
+func (s *Service) FindIncidentsInRadius(ctx context.Context, typeId *int64, lat, lon float64, radius int64, heading *float64) ([]models.IncidentWithDistance, error) {
+	if typeId != nil {
+		incidentType, err := s.incidents.FindIncidentTypeById(ctx, typeId)
 		if err != nil {
 			return nil, err
 		}
 
-		incidents[i].Type = completed.Type
-		incidents[i].Interactions = completed.Interactions
+		if incidentType == nil {
+			return nil, &ErrorWithCode{
+				Message: "Incident type does not exists",
+				Code:    http.StatusNotFound,
+			}
+		}
+	}
+
+	incidents, err := s.incidents.FindIncidentsInZone(ctx, &lat, &lon, radius, typeId)
+	if err != nil || heading == nil {
+		return incidents, err
+	}
+
+	filtered := make([]models.IncidentWithDistance, 0, len(incidents))
+	for _, incident := range incidents {
+		if incident.AffectsHeading(*heading, s.config.HeadingTolerance) {
+			filtered = append(filtered, incident)
+		}
+	}
+	return filtered, nil
+}
+
+// FindIncidentsInBoundingBox godoc
+// Récupère les incidents actifs d'une zone rectangulaire. Le nombre de résultats est plafonné
+// par la configuration, truncated indique que des incidents de la zone n'ont pas été retournés.
+func (s *Service) FindIncidentsInBoundingBox(ctx context.Context, typeId *int64, box *helpers.BoundingBox) (incidents []models.Incident, truncated bool, err error) {
+	if err := box.Validate(); err != nil {
+		return nil, false, &ErrorWithCode{
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if err := s.checkIncidentTypeExists(ctx, typeId); err != nil {
+		return nil, false, err
+	}
+
+	// Un incident supplémentaire est demandé pour savoir si le résultat est tronqué
+	incidents, err = s.incidents.FindIncidentsInBoundingBox(ctx, box, typeId, s.config.BboxMaxResults+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(incidents) > s.config.BboxMaxResults {
+		return incidents[:s.config.BboxMaxResults], true, nil
+	}
+
+	return incidents, false, nil
+}
+
+// FindIncidentsInGeohash godoc
+// Récupère les incidents actifs d'une cellule geohash, avec le même plafond que les zones rectangulaires
+func (s *Service) FindIncidentsInGeohash(ctx context.Context, typeId *int64, prefix string) (incidents []models.Incident, truncated bool, err error) {
+	if !helpers.IsGeohash(prefix) {
+		return nil, false, &ErrorWithCode{
+			Message: fmt.Sprintf("geohash must contain 1 to %d geohash characters", helpers.GeohashPrecision),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if err := s.checkIncidentTypeExists(ctx, typeId); err != nil {
+		return nil, false, err
+	}
+
+	incidents, err = s.incidents.FindIncidentsByGeohashPrefix(ctx, prefix, typeId, s.config.BboxMaxResults+1)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if len(incidents) > s.config.BboxMaxResults {
+		return incidents[:s.config.BboxMaxResults], true, nil
+	}
+
+	return incidents, false, nil
+}
+
+// checkIncidentTypeExists retourne une erreur 404 si le type de filtre n'existe pas
+func (s *Service) checkIncidentTypeExists(ctx context.Context, typeId *int64) error {
+	if typeId == nil {
+		return nil
+	}
+
+	incidentType, err := s.incidents.FindIncidentTypeById(ctx, typeId)
+	if err != nil {
+		return err
+	}
+
+	if incidentType == nil {
+		return &ErrorWithCode{
+			Message: "Incident type does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+// GetUserHistory godoc
+// Récupère une page de l'historique de l'utilisateur, le curseur de la page suivante
+// (nil s'il s'agit de la dernière page) et le nombre total d'incidents correspondant aux filtres
+func (s *Service) GetUserHistory(ctx context.Context, user *dto.PartialUserDTO, query *validations.UserHistoryValidator) ([]models.Incident, *helpers.Cursor, int, error) {
+	page, err := toPageQuery(&query.PageValidator)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	filters := &repository.HistoryFilters{
+		From:   query.From,
+		To:     query.To,
+		Before: query.Before,
+		TypeId: query.TypeId,
 	}
 
-	return incidents, err
+	incidents, total, err := s.incidents.FindUserHistory(ctx, user, filters, page)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	incidents, next := paginate(incidents, page.Limit, func(incident models.Incident) helpers.Cursor {
+		return helpers.Cursor{CreatedAt: incident.CreatedAt, ID: incident.ID}
+	})
+
+	return incidents, next, total, nil
 }
 
-func (s *Service) GetUserHistory(ctx context.Context, user *dto.PartialUserDTO) ([]models.Incident, error) {
-	return s.incidents.FindUserHistory(ctx, user)
+// GetUserActiveIncidents godoc
+// Récupère une page des incidents en cours signalés par l'utilisateur
+func (s *Service) GetUserActiveIncidents(ctx context.Context, user *dto.PartialUserDTO, query *validations.PageValidator) ([]models.Incident, *helpers.Cursor, int, error) {
+	page, err := toPageQuery(query)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	incidents, total, err := s.incidents.FindUserActiveIncidents(ctx, user, page)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	incidents, next := paginate(incidents, page.Limit, func(incident models.Incident) helpers.Cursor {
+		return helpers.Cursor{CreatedAt: incident.CreatedAt, ID: incident.ID}
+	})
+
+	return incidents, next, total, nil
+}
+
+func toPageQuery(page *validations.PageValidator) (*repository.PageQuery, error) {
+	query := &repository.PageQuery{
+		Limit: page.Limit,
+	}
+	if query.Limit == 0 {
+		query.Limit = validations.DefaultPageLimit
+	}
+
+	if page.Cursor != "" {
+		cursor, err := helpers.DecodeCursor(page.Cursor)
+		if err != nil {
+			return nil, &ErrorWithCode{
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			}
+		}
+		query.Cursor = cursor
+	}
+
+	return query, nil
+}
+
+// paginate retire l'élément supplémentaire demandé au repository
+// et retourne le curseur de la page suivante s'il en existe une
+func paginate[T any](items []T, limit int, cursorOf func(T) helpers.Cursor) ([]T, *helpers.Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+
+	items = items[:limit]
+	cursor := cursorOf(items[limit-1])
+	return items, &cursor
 }
 
 func toPtr[T any](t T) *T {
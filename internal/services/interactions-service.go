@@ -5,25 +5,51 @@ import (
 	"net/http"
 	"sort"
 	"supmap-users/internal/api/validations"
+	"supmap-users/internal/helpers"
 	"supmap-users/internal/models"
 	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/photos"
 	rediss "supmap-users/internal/services/redis"
 	"time"
 )
 
-func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDTO, body *validations.CreateInteractionValidator) (*models.Interaction, error) {
+func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDTO, body *validations.CreateInteractionValidator, uploads [][]byte) (*models.Interaction, error) {
+	prepared, err := s.preparePhotos(uploads)
+	if err != nil {
+		return nil, err
+	}
+	return s.createInteraction(ctx, user, body, prepared)
+}
+
+// createInteraction enregistre l'interaction et ses photos déjà traitées. Les fichiers des photos sont
+// enregistrés avant la transaction et supprimés si leurs lignes ne sont pas validées en base.
+func (s *Service) createInteraction(ctx context.Context, user *dto.PartialUserDTO, body *validations.CreateInteractionValidator, prepared []*photos.Processed) (*models.Interaction, error) {
+	stored, err := s.storePhotos(ctx, prepared, user.ID)
+	if err != nil {
+		return nil, err
+	}
 
 	tx, err := s.incidents.AskForTx(ctx)
 	if err != nil {
+		s.deletePhotoBlobs(ctx, stored)
 		return nil, err
 	}
+	photosInserted := false
 	defer func() {
 		if err != nil {
 			s.log.Info("Rollback de la transaction")
 			_ = tx.Rollback()
+			photosInserted = false
 		} else {
 			s.log.Info("Commit de la transaction")
-			_ = tx.Commit()
+			if commitErr := tx.Commit(); commitErr != nil {
+				s.log.Error("failed to commit interaction", "error", commitErr)
+				photosInserted = false
+			}
+		}
+		// Les signalements refusés (incident verrouillé, interaction trop récente...) ne conservent pas leurs photos
+		if !photosInserted {
+			s.deletePhotoBlobs(ctx, stored)
 		}
 	}()
 
@@ -54,6 +80,13 @@ func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDT
 		}
 	}
 
+	if body.SeverityChange != nil && !*body.IsStillPresent {
+		return nil, &ErrorWithCode{
+			Message: "Severity can only change on an incident still present",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
 	//	Check si l'utilisateur à déjà intéragis avec l'incident
 	//	Si intéragis, il y a moins d'une heure → Too many requests
 	//	Sinon on accepte l'intéraction
@@ -71,6 +104,7 @@ func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDT
 		IncidentID:     incident.ID,
 		UserID:         user.ID,
 		IsStillPresent: *body.IsStillPresent,
+		SeverityChange: body.SeverityChange,
 		CreatedAt:      time.Now(),
 	}
 
@@ -78,6 +112,17 @@ func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDT
 		return nil, err
 	}
 
+	for i := range stored {
+		stored[i].IncidentID = incident.ID
+		stored[i].InteractionID = &toInsert.ID
+	}
+	if err = s.incidents.InsertPhotosTx(ctx, tx, stored); err != nil {
+		return nil, err
+	}
+	photosInserted = true
+
+	severityChanged := body.SeverityChange != nil && incident.ChangeSeverity(*body.SeverityChange)
+
 	err = s.incidents.UpdateIncidentTx(ctx, tx, incident)
 	if err != nil {
 		return nil, err
@@ -134,7 +179,32 @@ func (s *Service) CreateInteraction(ctx context.Context, user *dto.PartialUserDT
 			Data:   *dto.IncidentToRedis(incident),
 			Action: rediss.Certified,
 		})
+	} else if severityChanged {
+		_ = s.redis.PublishMessage(s.config.IncidentChannel, &rediss.IncidentMessage{
+			Data:   *dto.IncidentToRedis(incident),
+			Action: rediss.Updated,
+		})
 	}
 
 	return inserted, err
 }
+
+// GetUserInteractions godoc
+// Récupère une page des interactions de l'utilisateur, de la plus récente à la plus ancienne
+func (s *Service) GetUserInteractions(ctx context.Context, user *dto.PartialUserDTO, query *validations.PageValidator) ([]models.Interaction, *helpers.Cursor, int, error) {
+	page, err := toPageQuery(query)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	interactions, total, err := s.interactions.FindUserInteractions(ctx, user, page)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+
+	interactions, next := paginate(interactions, page.Limit, func(interaction models.Interaction) helpers.Cursor {
+		return helpers.Cursor{CreatedAt: interaction.CreatedAt, ID: interaction.ID}
+	})
+
+	return interactions, next, total, nil
+}
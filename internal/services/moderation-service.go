@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/models"
+	"supmap-users/internal/models/dto"
+	rediss "supmap-users/internal/services/redis"
+	"time"
+)
+
+// ExpireIncident godoc
+// Force l'expiration d'un incident en cours par un modérateur
+func (s *Service) ExpireIncident(ctx context.Context, id int64) (incident *models.Incident, err error) {
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	incident, err = s.incidents.FindIncidentByIdTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident == nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	if incident.DeletedAt != nil {
+		return nil, &ErrorWithCode{
+			Message: "This incident is already expired",
+			Code:    http.StatusConflict,
+		}
+	}
+
+	now := time.Now()
+	incident.DeletedAt = &now
+	if err = s.incidents.UpdateIncidentTx(ctx, tx, incident); err != nil {
+		return nil, err
+	}
+
+	// Le message n'est publié qu'une fois la modification validée en base
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// La modification est enregistrée, un échec de publication ne la remet pas en cause
+	if err := s.redis.PublishMessage(s.config.IncidentChannel, &rediss.IncidentMessage{
+		Data:   *dto.IncidentToRedis(incident),
+		Action: rediss.Deleted,
+	}); err != nil {
+		s.log.Error("failed to publish incident expiration", "id", incident.ID, "error", err)
+	}
+
+	return incident, nil
+}
+
+// RestoreIncident godoc
+// Rétablit un incident expiré à tort, sa durée de vie sans confirmation repart de zéro.
+// Un incident ayant dépassé sa durée de vie maximale ne peut pas être rétabli.
+func (s *Service) RestoreIncident(ctx context.Context, id int64) (incident *models.Incident, err error) {
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	incident, err = s.incidents.FindIncidentByIdTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident == nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	if incident.DeletedAt == nil {
+		return nil, &ErrorWithCode{
+			Message: "This incident is not expired",
+			Code:    http.StatusConflict,
+		}
+	}
+
+	// Un incident ayant dépassé sa durée de vie maximale serait aussitôt expiré à nouveau par le scheduler
+	if time.Since(incident.CreatedAt) > incident.SeverityLifetime(incident.Type.GlobalLifetime, s.config.SeverityLifetimeFactors) {
+		return nil, &ErrorWithCode{
+			Message: "This incident has reached its maximum lifetime",
+			Code:    http.StatusConflict,
+		}
+	}
+
+	if err = s.incidents.RestoreIncidentTx(ctx, tx, incident); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// La modification est enregistrée, un échec de publication ne la remet pas en cause
+	if err := s.redis.PublishMessage(s.config.IncidentChannel, &rediss.IncidentMessage{
+		Data:   *dto.IncidentToRedis(incident),
+		Action: rediss.Restored,
+	}); err != nil {
+		s.log.Error("failed to publish incident restoration", "id", incident.ID, "error", err)
+	}
+
+	return incident, nil
+}
+
+// UpdateIncidentType godoc
+// Réaffecte un incident à un autre type d'incident
+func (s *Service) UpdateIncidentType(ctx context.Context, id int64, body *validations.UpdateIncidentTypeValidator) (incident *models.Incident, err error) {
+	incidentType, err := s.incidents.FindIncidentTypeById(ctx, &body.TypeId)
+	if err != nil {
+		return nil, err
+	}
+
+	if incidentType == nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident type does not exists",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if incidentType.DisabledAt != nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident type is disabled",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	incident, err = s.incidents.FindIncidentByIdTx(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if incident == nil {
+		return nil, &ErrorWithCode{
+			Message: "Incident does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	incident.TypeID = incidentType.ID
+	incident.Type = incidentType
+	if err = s.incidents.UpdateIncidentTx(ctx, tx, incident); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	// La modification est enregistrée, un échec de publication ne la remet pas en cause
+	if err := s.redis.PublishMessage(s.config.IncidentChannel, &rediss.IncidentMessage{
+		Data:   *dto.IncidentToRedis(incident),
+		Action: rediss.Updated,
+	}); err != nil {
+		s.log.Error("failed to publish incident type update", "id", incident.ID, "error", err)
+	}
+
+	return incident, nil
+}
+
+// DeleteInteraction godoc
+// Supprime une interaction abusive et notifie la mise à jour de l'incident associé
+func (s *Service) DeleteInteraction(ctx context.Context, id int64) (err error) {
+	// Les fichiers des photos ne sont supprimés qu'une fois la transaction validée
+	var attached []models.Photo
+	defer func() {
+		if err == nil {
+			s.deletePhotoBlobs(ctx, attached)
+		}
+	}()
+
+	tx, err := s.incidents.AskForTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	interaction, err := s.interactions.FindInteractionByIdTx(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	if interaction == nil {
+		return &ErrorWithCode{
+			Message: "Interaction does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	// Verrouille l'incident avant de modifier ses interactions
+	if _, err = s.incidents.FindIncidentByIdTx(ctx, tx, interaction.IncidentID); err != nil {
+		return err
+	}
+
+	if attached, err = s.incidents.FindInteractionPhotosTx(ctx, tx, interaction.ID); err != nil {
+		return err
+	}
+
+	if err = s.interactions.DeleteTx(ctx, tx, interaction); err != nil {
+		return err
+	}
+
+	incident, err := s.incidents.FindIncidentByIdTx(ctx, tx, interaction.IncidentID)
+	if err != nil {
+		return err
+	}
+
+	// Date la modification de l'incident pour la synchronisation différentielle
+	if err = s.incidents.UpdateIncidentTx(ctx, tx, incident); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if err := s.redis.PublishMessage(s.config.IncidentChannel, &rediss.IncidentMessage{
+		Data:   *dto.IncidentToRedis(incident),
+		Action: rediss.Updated,
+	}); err != nil {
+		s.log.Error("failed to publish incident update", "id", incident.ID, "error", err)
+	}
+
+	return nil
+}
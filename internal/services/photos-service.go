@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"supmap-users/internal/models"
+	"supmap-users/internal/services/photos"
+	"supmap-users/internal/services/storage"
+	"time"
+)
+
+// photoKeyPattern correspond aux clés générées par storePhotos, les autres clés ne sont pas servies
+var photoKeyPattern = regexp.MustCompile(`^[0-9a-f]{32}(_thumb)?\.jpg$`)
+
+// preparePhotos vérifie le nombre, la taille et le format des photos envoyées puis les traite,
+// avant tout enregistrement afin de refuser le signalement si une photo est invalide
+func (s *Service) preparePhotos(uploads [][]byte) ([]*photos.Processed, error) {
+	if len(uploads) > s.config.PhotosMaxCount {
+		return nil, &ErrorWithCode{
+			Message: fmt.Sprintf("Too many photos, at most %d are allowed", s.config.PhotosMaxCount),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	prepared := make([]*photos.Processed, len(uploads))
+	for i, upload := range uploads {
+		if int64(len(upload)) > s.config.PhotosMaxSize {
+			return nil, &ErrorWithCode{
+				Message: fmt.Sprintf("Photo %d exceeds the maximum size of %d bytes", i+1, s.config.PhotosMaxSize),
+				Code:    http.StatusRequestEntityTooLarge,
+			}
+		}
+
+		processed, err := photos.Process(upload, s.config.PhotosMaxDimension, s.config.PhotosThumbnailSize)
+		if err != nil {
+			return nil, &ErrorWithCode{
+				Message: fmt.Sprintf("Invalid photo %d: %s", i+1, err),
+				Code:    http.StatusBadRequest,
+			}
+		}
+		prepared[i] = processed
+	}
+	return prepared, nil
+}
+
+// storePhotos enregistre les photos et leurs miniatures dans le stockage et retourne les lignes à insérer,
+// l'incident et l'interaction étant renseignés par l'appelant. Les fichiers sont enregistrés avant d'ouvrir
+// la transaction pour ne pas la garder ouverte pendant l'envoi vers le stockage.
+// En cas d'erreur, les fichiers déjà enregistrés sont supprimés.
+func (s *Service) storePhotos(ctx context.Context, prepared []*photos.Processed, userID int64) ([]models.Photo, error) {
+	stored := make([]models.Photo, 0, len(prepared))
+	for _, processed := range prepared {
+		id, err := randomKey()
+		if err != nil {
+			s.deletePhotoBlobs(ctx, stored)
+			return nil, err
+		}
+
+		photo := models.Photo{
+			UserID:       userID,
+			Key:          id + ".jpg",
+			ThumbnailKey: id + "_thumb.jpg",
+			Width:        processed.Width,
+			Height:       processed.Height,
+			CreatedAt:    time.Now(),
+		}
+
+		if err := s.storage.Put(ctx, photo.Key, processed.Photo, photos.ContentType); err != nil {
+			s.deletePhotoBlobs(ctx, stored)
+			return nil, fmt.Errorf("failed to store photo: %w", err)
+		}
+		stored = append(stored, photo)
+
+		if err := s.storage.Put(ctx, photo.ThumbnailKey, processed.Thumbnail, photos.ContentType); err != nil {
+			s.deletePhotoBlobs(ctx, stored)
+			return nil, fmt.Errorf("failed to store photo thumbnail: %w", err)
+		}
+	}
+	return stored, nil
+}
+
+// deletePhotoBlobs supprime les fichiers des photos, les erreurs sont seulement journalisées
+func (s *Service) deletePhotoBlobs(ctx context.Context, photos []models.Photo) {
+	ctx = context.WithoutCancel(ctx)
+	for _, photo := range photos {
+		for _, key := range []string{photo.Key, photo.ThumbnailKey} {
+			if err := s.storage.Delete(ctx, key); err != nil {
+				s.log.Error("failed to delete photo", "key", key, "error", err)
+			}
+		}
+	}
+}
+
+// GetPhoto godoc
+// Récupère le contenu d'une photo ou d'une miniature à partir de sa clé
+func (s *Service) GetPhoto(ctx context.Context, key string) ([]byte, error) {
+	if !photoKeyPattern.MatchString(key) {
+		return nil, &ErrorWithCode{
+			Message: "Photo does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+
+	content, err := s.storage.Get(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, &ErrorWithCode{
+			Message: "Photo does not exists",
+			Code:    http.StatusNotFound,
+		}
+	}
+	return content, err
+}
+
+func randomKey() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate photo key: %w", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
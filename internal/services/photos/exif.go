@@ -0,0 +1,79 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// orientationTag est le tag EXIF indiquant la rotation à appliquer à l'image pour l'afficher
+const orientationTag = 0x0112
+
+// jpegOrientation lit l'orientation EXIF d'une image JPEG, 1 (aucune transformation) si elle est absente.
+// L'orientation doit être appliquée aux pixels avant le réencodage, qui supprime les métadonnées EXIF.
+func jpegOrientation(content []byte) int {
+	if len(content) < 4 || content[0] != 0xFF || content[1] != 0xD8 {
+		return 1
+	}
+
+	for offset := 2; offset+4 <= len(content); {
+		if content[offset] != 0xFF {
+			return 1
+		}
+		marker := content[offset+1]
+		// Les données de l'image commencent après SOS, les métadonnées sont toutes avant
+		if marker == 0xDA || marker == 0xD9 {
+			return 1
+		}
+
+		length := int(binary.BigEndian.Uint16(content[offset+2:]))
+		end := offset + 2 + length
+		if length < 2 || end > len(content) {
+			return 1
+		}
+
+		segment := content[offset+4 : end]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+		offset = end
+	}
+	return 1
+}
+
+// tiffOrientation cherche le tag d'orientation dans le premier IFD de l'en-tête TIFF du segment EXIF
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+
+	entries := int(order.Uint16(tiff[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			orientation := int(order.Uint16(tiff[entry+8:]))
+			if orientation < 1 || orientation > 8 {
+				return 1
+			}
+			return orientation
+		}
+	}
+	return 1
+}
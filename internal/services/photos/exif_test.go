@@ -0,0 +1,151 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment construit un segment APP1 EXIF dont le premier IFD contient le tag d'orientation
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	order.PutUint16(tiff[10:], orientationTag)
+	order.PutUint16(tiff[12:], 3) // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	return appSegment(0xE1, append([]byte("Exif\x00\x00"), tiff...))
+}
+
+func appSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withSegments insère les segments après le marqueur SOI d'une image JPEG
+func withSegments(content []byte, segments ...[]byte) []byte {
+	result := append([]byte{}, content[:2]...)
+	for _, segment := range segments {
+		result = append(result, segment...)
+	}
+	return append(result, content[2:]...)
+}
+
+func testJPEG(t *testing.T, width int, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	content := testJPEG(t, 4, 4)
+
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			t.Run(fmt.Sprintf("%d %s", orientation, order), func(t *testing.T) {
+				got := jpegOrientation(withSegments(content, exifSegment(order, orientation)))
+				if got != int(orientation) {
+					t.Errorf("expected orientation %d, got %d", orientation, got)
+				}
+			})
+		}
+	}
+
+	jfif := appSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	if got := jpegOrientation(withSegments(content, jfif, exifSegment(binary.BigEndian, 6))); got != 6 {
+		t.Errorf("expected the EXIF segment after JFIF to be read, got %d", got)
+	}
+	if got := jpegOrientation(content); got != 1 {
+		t.Errorf("expected orientation 1 without EXIF, got %d", got)
+	}
+}
+
+func TestJPEGOrientationMalformed(t *testing.T) {
+	content := testJPEG(t, 4, 4)
+	valid := exifSegment(binary.LittleEndian, 6)
+
+	// corrupt modifie une copie du segment EXIF valide, l'en-tête TIFF commençant à l'octet 10
+	corrupt := func(change func(segment []byte) []byte) []byte {
+		return withSegments(content, change(append([]byte{}, valid...)))
+	}
+
+	tests := []struct {
+		name    string
+		content []byte
+	}{
+		{"empty", nil},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n")},
+		{"only SOI", []byte{0xFF, 0xD8}},
+		{"segment length beyond the file", corrupt(func(s []byte) []byte {
+			binary.BigEndian.PutUint16(s[2:], 0xFFFF)
+			return s
+		})},
+		{"segment length below 2", corrupt(func(s []byte) []byte {
+			binary.BigEndian.PutUint16(s[2:], 1)
+			return s
+		})},
+		{"missing marker prefix", corrupt(func(s []byte) []byte {
+			s[0] = 0x00
+			return s
+		})},
+		{"TIFF header too short", withSegments(content, appSegment(0xE1, []byte("Exif\x00\x00II*\x00")))},
+		{"unknown byte order", corrupt(func(s []byte) []byte {
+			copy(s[10:], "XX")
+			return s
+		})},
+		{"IFD offset inside the header", corrupt(func(s []byte) []byte {
+			binary.LittleEndian.PutUint32(s[14:], 4)
+			return s
+		})},
+		{"IFD offset beyond the segment", corrupt(func(s []byte) []byte {
+			binary.LittleEndian.PutUint32(s[14:], 0xFFFFFFFF)
+			return s
+		})},
+		{"entry count beyond the segment", corrupt(func(s []byte) []byte {
+			binary.LittleEndian.PutUint16(s[18:], 0xFFFF)
+			binary.LittleEndian.PutUint16(s[20:], 0x0001)
+			return s
+		})},
+		{"orientation 0", withSegments(content, exifSegment(binary.LittleEndian, 0))},
+		{"orientation 9", withSegments(content, exifSegment(binary.LittleEndian, 9))},
+		{"orientation 0xFFFF", withSegments(content, exifSegment(binary.BigEndian, 0xFFFF))},
+		{"EXIF after the image data", append(append([]byte{0xFF, 0xD8}, 0xFF, 0xDA, 0x00, 0x02), valid...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jpegOrientation(tt.content); got != 1 {
+				t.Errorf("expected orientation 1, got %d", got)
+			}
+		})
+	}
+}
+
+func TestJPEGOrientationTruncated(t *testing.T) {
+	content := withSegments([]byte{0xFF, 0xD8}, exifSegment(binary.BigEndian, 8))
+
+	// Un fichier coupé à n'importe quel octet ne doit pas provoquer de panique
+	for i := range len(content) {
+		if got := jpegOrientation(content[:i]); got != 1 {
+			t.Errorf("expected orientation 1 for content truncated at %d bytes, got %d", i, got)
+		}
+	}
+	if got := jpegOrientation(content); got != 8 {
+		t.Errorf("expected orientation 8 for the complete segment, got %d", got)
+	}
+}
@@ -0,0 +1,158 @@
+package photos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+// maxPixels limite la taille des images décodées : une image JPEG très compressée de quelques
+// mégaoctets peut occuper plusieurs centaines de mégaoctets une fois décodée. 16 millions de pixels
+// (64 Mo en RGBA) suffisent aux photos d'un téléphone, soit 12 millions de pixels en général.
+const maxPixels = 16_000_000
+
+// Qualité de l'encodage JPEG des photos et des miniatures
+const (
+	photoQuality     = 85
+	thumbnailQuality = 75
+)
+
+// ContentType est le type des photos et des miniatures enregistrées
+const ContentType = "image/jpeg"
+
+// ErrUnsupportedFormat est retournée lorsque le fichier n'est pas une image JPEG ou PNG
+var ErrUnsupportedFormat = errors.New("photo must be a JPEG or PNG image")
+
+// Processed est une photo prête à être enregistrée
+type Processed struct {
+	Photo     []byte
+	Thumbnail []byte
+	Width     int
+	Height    int
+}
+
+// Process redimensionne la photo pour que son plus grand côté ne dépasse pas maxDimension et génère
+// sa miniature. Les images sont réencodées en JPEG, ce qui supprime les métadonnées EXIF et notamment
+// la position GPS ; l'orientation EXIF est appliquée aux pixels avant d'être perdue.
+func Process(content []byte, maxDimension int, thumbnailSize int) (*Processed, error) {
+	switch http.DetectContentType(content) {
+	case "image/jpeg", "image/png":
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, fmt.Errorf("photo dimensions %dx%d are not supported", config.Width, config.Height)
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		return nil, ErrUnsupportedFormat
+	}
+
+	orientation := jpegOrientation(content)
+	photo := orient(resize(decoded, maxDimension), orientation)
+	thumbnail := resize(photo, thumbnailSize)
+
+	processed := &Processed{
+		Width:  photo.Bounds().Dx(),
+		Height: photo.Bounds().Dy(),
+	}
+	if processed.Photo, err = encode(photo, photoQuality); err != nil {
+		return nil, err
+	}
+	if processed.Thumbnail, err = encode(thumbnail, thumbnailQuality); err != nil {
+		return nil, err
+	}
+	return processed, nil
+}
+
+func encode(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, fmt.Errorf("failed to encode photo: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// resize réduit l'image pour que son plus grand côté ne dépasse pas maxDimension, en moyennant
+// les pixels sources couverts par chaque pixel. Les zones transparentes sont placées sur un fond blanc.
+func resize(src image.Image, maxDimension int) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if longest := max(width, height); longest > maxDimension {
+		width = max(1, width*maxDimension/longest)
+		height = max(1, height*maxDimension/longest)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := bounds.Min.Y + y*bounds.Dy()/height
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*bounds.Dy()/height)
+		for x := 0; x < width; x++ {
+			x0 := bounds.Min.X + x*bounds.Dx()/width
+			x1 := max(x0+1, bounds.Min.X+(x+1)*bounds.Dx()/width)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					background := uint64(0xffff - ca)
+					r += uint64(cr) + background
+					g += uint64(cg) + background
+					b += uint64(cb) + background
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: 0xff})
+		}
+	}
+	return dst
+}
+
+// orient applique l'orientation EXIF (1 à 8) pour obtenir l'image telle qu'elle doit être affichée
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	width, height := src.Bounds().Dx(), src.Bounds().Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // Miroir horizontal
+				sx, sy = width-1-x, y
+			case 3: // Rotation de 180°
+				sx, sy = width-1-x, height-1-y
+			case 4: // Miroir vertical
+				sx, sy = x, height-1-y
+			case 5: // Transposition
+				sx, sy = y, x
+			case 6: // Rotation de 90° dans le sens horaire
+				sx, sy = y, height-1-x
+			case 7: // Transposition inverse
+				sx, sy = width-1-y, height-1-x
+			case 8: // Rotation de 90° dans le sens antihoraire
+				sx, sy = width-1-y, x
+			}
+			dst.SetRGBA(x, y, src.RGBAAt(sx, sy))
+		}
+	}
+	return dst
+}
@@ -0,0 +1,209 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		name         string
+		width        int
+		height       int
+		maxDimension int
+		wantWidth    int
+		wantHeight   int
+	}{
+		{"landscape", 100, 50, 10, 10, 5},
+		{"portrait", 50, 100, 10, 5, 10},
+		{"already small", 8, 6, 10, 8, 6},
+		{"exactly the maximum", 10, 10, 10, 10, 10},
+		{"thin strip", 1000, 1, 10, 10, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resized := resize(image.NewRGBA(image.Rect(0, 0, tt.width, tt.height)), tt.maxDimension)
+			if resized.Bounds().Dx() != tt.wantWidth || resized.Bounds().Dy() != tt.wantHeight {
+				t.Errorf("expected %dx%d, got %dx%d", tt.wantWidth, tt.wantHeight, resized.Bounds().Dx(), resized.Bounds().Dy())
+			}
+		})
+	}
+}
+
+func TestResizeAveragesPixels(t *testing.T) {
+	// Damier noir et blanc de 2x2 pixels réduit à un pixel gris
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{A: 0xff})
+	src.SetRGBA(1, 0, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	src.SetRGBA(0, 1, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+	src.SetRGBA(1, 1, color.RGBA{A: 0xff})
+
+	if got := resize(src, 1).RGBAAt(0, 0); got != (color.RGBA{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff}) {
+		t.Errorf("expected mid gray, got %v", got)
+	}
+
+	// Les bornes de l'image source ne commencent pas forcément à l'origine
+	offset := src.SubImage(image.Rect(1, 0, 2, 1))
+	if got := resize(offset, 1).RGBAAt(0, 0); got != (color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}) {
+		t.Errorf("expected white pixel from the sub image, got %v", got)
+	}
+}
+
+func TestResizeFlattensAlpha(t *testing.T) {
+	tests := []struct {
+		name  string
+		color color.NRGBA
+		want  color.RGBA
+	}{
+		{"transparent", color.NRGBA{R: 0xff, A: 0}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}},
+		{"half transparent red", color.NRGBA{R: 0xff, A: 0x80}, color.RGBA{R: 0xff, G: 0x7f, B: 0x7f, A: 0xff}},
+		{"opaque blue", color.NRGBA{B: 0xff, A: 0xff}, color.RGBA{B: 0xff, A: 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+			src.SetNRGBA(0, 0, tt.color)
+			if got := resize(src, 1).RGBAAt(0, 0); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOrient(t *testing.T) {
+	// Image source de 3x2 pixels, chaque pixel étant identifié par sa lettre :
+	// a b c
+	// d e f
+	letters := "abcdef"
+	src := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for i := range letters {
+		src.SetRGBA(i%3, i/3, color.RGBA{R: letters[i], A: 0xff})
+	}
+
+	tests := []struct {
+		orientation int
+		want        []string
+	}{
+		{1, []string{"abc", "def"}},
+		{2, []string{"cba", "fed"}},
+		{3, []string{"fed", "cba"}},
+		{4, []string{"def", "abc"}},
+		{5, []string{"ad", "be", "cf"}},
+		{6, []string{"da", "eb", "fc"}},
+		{7, []string{"fc", "eb", "da"}},
+		{8, []string{"cf", "be", "ad"}},
+		{0, []string{"abc", "def"}},
+		{9, []string{"abc", "def"}},
+	}
+
+	for _, tt := range tests {
+		oriented := orient(src, tt.orientation)
+		var rows []string
+		for y := 0; y < oriented.Bounds().Dy(); y++ {
+			row := make([]byte, oriented.Bounds().Dx())
+			for x := range row {
+				row[x] = oriented.RGBAAt(x, y).R
+			}
+			rows = append(rows, string(row))
+		}
+		if !slices.Equal(rows, tt.want) {
+			t.Errorf("orientation %d: expected %v, got %v", tt.orientation, tt.want, rows)
+		}
+	}
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	t.Run("resized photo and thumbnail", func(t *testing.T) {
+		processed, err := Process(encodePNG(t, image.NewRGBA(image.Rect(0, 0, 400, 200))), 100, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if processed.Width != 100 || processed.Height != 50 {
+			t.Errorf("expected 100x50, got %dx%d", processed.Width, processed.Height)
+		}
+		thumbnail, err := jpeg.DecodeConfig(bytes.NewReader(processed.Thumbnail))
+		if err != nil || thumbnail.Width != 20 || thumbnail.Height != 10 {
+			t.Errorf("expected a 20x10 JPEG thumbnail, got %+v, %v", thumbnail, err)
+		}
+	})
+
+	t.Run("transparent PNG on a white background", func(t *testing.T) {
+		processed, err := Process(encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 16, 16))), 100, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		photo, err := jpeg.Decode(bytes.NewReader(processed.Photo))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r, g, b, _ := photo.At(8, 8).RGBA(); r>>8 < 0xf0 || g>>8 < 0xf0 || b>>8 < 0xf0 {
+			t.Errorf("expected a white pixel, got %d %d %d", r>>8, g>>8, b>>8)
+		}
+	})
+
+	t.Run("EXIF orientation applied and metadata removed", func(t *testing.T) {
+		content := withSegments(testJPEG(t, 40, 20), exifSegment(binary.BigEndian, 6))
+		processed, err := Process(content, 100, 20)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if processed.Width != 20 || processed.Height != 40 {
+			t.Errorf("expected the rotated 20x40 photo, got %dx%d", processed.Width, processed.Height)
+		}
+		if bytes.Contains(processed.Photo, []byte("Exif\x00\x00")) {
+			t.Error("expected EXIF metadata to be removed")
+		}
+	})
+
+	t.Run("unsupported format", func(t *testing.T) {
+		if _, err := Process([]byte("GIF89a"), 100, 20); !errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("expected ErrUnsupportedFormat, got %v", err)
+		}
+	})
+
+	t.Run("corrupted JPEG", func(t *testing.T) {
+		content := testJPEG(t, 40, 20)
+		if _, err := Process(content[:len(content)/2], 100, 20); err == nil {
+			t.Error("expected an error for a truncated JPEG")
+		}
+	})
+
+	t.Run("too many pixels", func(t *testing.T) {
+		if _, err := Process(withDimensions(t, encodePNG(t, image.NewRGBA(image.Rect(0, 0, 1, 1))), 4000, 4001), 100, 20); err == nil || errors.Is(err, ErrUnsupportedFormat) {
+			t.Errorf("expected a dimensions error, got %v", err)
+		}
+	})
+}
+
+// withDimensions modifie les dimensions annoncées par l'en-tête IHDR d'une image PNG sans changer ses données
+func withDimensions(t *testing.T, content []byte, width uint32, height uint32) []byte {
+	t.Helper()
+	// Signature (8 octets), longueur (4), type "IHDR" (4), puis largeur et hauteur
+	if string(content[12:16]) != "IHDR" {
+		t.Fatal("expected IHDR as first PNG chunk")
+	}
+	patched := append([]byte{}, content...)
+	binary.BigEndian.PutUint32(patched[16:], width)
+	binary.BigEndian.PutUint32(patched[20:], height)
+	binary.BigEndian.PutUint32(patched[29:], crc32.ChecksumIEEE(patched[12:29]))
+	return patched
+}
@@ -10,9 +10,22 @@ const (
 	Create    Action = "create"
 	Certified Action = "certified"
 	Deleted   Action = "deleted"
+	Restored  Action = "restored"
+	Updated   Action = "updated"
 )
 
 type IncidentMessage struct {
 	Data   dto.IncidentRedis `json:"data"`
 	Action Action            `json:"action"`
 }
+
+type TypeMessage struct {
+	Data   dto.TypeDTO `json:"data"`
+	Action Action      `json:"action"`
+}
+
+// AuthInvalidationMessage est publié par le service users lorsque les sessions d'un
+// utilisateur ne doivent plus être acceptées (déconnexion, changement de rôle)
+type AuthInvalidationMessage struct {
+	UserID int64 `json:"user_id"`
+}
@@ -5,6 +5,7 @@ import (
 	json2 "encoding/json"
 	"github.com/redis/go-redis/v9"
 	"log/slog"
+	"time"
 )
 
 type Redis struct {
@@ -43,6 +44,39 @@ func (r *Redis) publisher(ctx context.Context) {
 	}
 }
 
+// Subscribe écoute un canal Redis jusqu'à l'annulation du contexte
+func (r *Redis) Subscribe(ctx context.Context, channel string) <-chan *redis.Message {
+	pubsub := r.client.Subscribe(ctx, channel)
+	go func() {
+		<-ctx.Done()
+		if err := pubsub.Close(); err != nil {
+			r.log.Error("failed to close redis subscription", "channel", channel, "error", err)
+		}
+	}()
+	return pubsub.Channel()
+}
+
+// GetValues retourne les valeurs associées aux clés, nil pour les clés absentes
+func (r *Redis) GetValues(ctx context.Context, keys ...string) ([]*string, error) {
+	values, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*string, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			result[i] = &str
+		}
+	}
+	return result, nil
+}
+
+// SetValue associe une valeur à une clé pour une durée limitée
+func (r *Redis) SetValue(ctx context.Context, key string, value string, ttl time.Duration) error {
+	return r.client.Set(ctx, key, value, ttl).Err()
+}
+
 func (r *Redis) PublishMessage(channel string, payload any) error {
 	json, err := json2.Marshal(payload)
 	if err != nil {
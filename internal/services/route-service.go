@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+)
+
+// Longueur des tronçons de l'itinéraire requêtés séparément en base de données
+const routeChunkLength = 10000
+
+// FindIncidentsAlongRoute godoc
+// Récupère les incidents actifs situés à moins de buffer mètres de l'itinéraire,
+// triés selon leur position le long de celui-ci. Avec same_direction, les incidents signalés
+// dans le sens opposé au segment de l'itinéraire le plus proche sont ignorés.
+func (s *Service) FindIncidentsAlongRoute(ctx context.Context, body *validations.RouteIncidentsValidator) ([]models.IncidentOnRoute, error) {
+	route, err := decodeRoute(body)
+	if err != nil {
+		return nil, &ErrorWithCode{
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if body.TypeId != nil {
+		incidentType, err := s.incidents.FindIncidentTypeById(ctx, body.TypeId)
+		if err != nil {
+			return nil, err
+		}
+
+		if incidentType == nil {
+			return nil, &ErrorWithCode{
+				Message: "Incident type does not exists",
+				Code:    http.StatusNotFound,
+			}
+		}
+	}
+
+	incidents, err := s.incidents.FindIncidentsInBoundingBoxes(ctx, route.Chunks(routeChunkLength, body.Buffer), body.TypeId)
+	if err != nil {
+		return nil, err
+	}
+
+	var onRoute []models.IncidentOnRoute
+	for _, incident := range incidents {
+		along, lateral, bearing := route.Project(incident.Latitude, incident.Longitude)
+		if lateral > body.Buffer {
+			continue
+		}
+		if body.SameDirection && !incident.AffectsHeading(bearing, s.config.HeadingTolerance) {
+			continue
+		}
+
+		onRoute = append(onRoute, models.IncidentOnRoute{
+			Incident:           incident,
+			AlongRouteDistance: along,
+			LateralDistance:    lateral,
+		})
+	}
+
+	sort.SliceStable(onRoute, func(i, j int) bool {
+		return onRoute[i].AlongRouteDistance < onRoute[j].AlongRouteDistance
+	})
+
+	return onRoute, nil
+}
+
+func decodeRoute(body *validations.RouteIncidentsValidator) (helpers.Route, error) {
+	var route helpers.Route
+
+	if body.Geometry != nil {
+		// Les coordonnées GeoJSON sont exprimées dans l'ordre [longitude, latitude]
+		for _, coordinates := range body.Geometry.Coordinates {
+			route = append(route, helpers.Point{Lat: coordinates[1], Lon: coordinates[0]})
+		}
+	} else {
+		precision := body.Precision
+		if precision == 0 {
+			precision = 5
+		}
+
+		points, err := helpers.DecodePolyline(body.Polyline, precision)
+		if err != nil {
+			return nil, err
+		}
+		route = points
+	}
+
+	if len(route) < 2 {
+		return nil, errors.New("route must contain at least two points")
+	}
+
+	for _, point := range route {
+		if point.Lat < -90 || point.Lat > 90 || point.Lon < -180 || point.Lon > 180 {
+			return nil, errors.New("route contains invalid coordinates")
+		}
+	}
+
+	return route, nil
+}
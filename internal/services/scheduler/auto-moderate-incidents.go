@@ -11,7 +11,7 @@ import (
 
 // CheckLifetimeWithoutConfirmation godoc
 // Vérifie les incidents en cours qui n'ont pas eu d'intéraction
-// selon le temps définit par type d'incident
+// selon le temps définit par type d'incident, ajusté selon leur gravité
 func (s *Scheduler) CheckLifetimeWithoutConfirmation(ctx context.Context, exec *bun.Tx) {
 	incidents, err := s.incidents.GetAllActive(ctx, exec)
 	if err != nil {
@@ -20,8 +20,8 @@ func (s *Scheduler) CheckLifetimeWithoutConfirmation(ctx context.Context, exec *
 	}
 
 	for _, incident := range incidents {
-		noInteractionThreshold := time.Duration(incident.Type.LifetimeWithoutConfirmation)
-		if time.Since(incident.UpdatedAt) > noInteractionThreshold*time.Second {
+		noInteractionThreshold := incident.SeverityLifetime(incident.Type.LifetimeWithoutConfirmation, s.config.SeverityLifetimeFactors)
+		if time.Since(incident.UpdatedAt) > noInteractionThreshold {
 			now := time.Now()
 			incident.DeletedAt = &now
 
@@ -44,7 +44,7 @@ func (s *Scheduler) CheckLifetimeWithoutConfirmation(ctx context.Context, exec *
 }
 
 // CheckGlobalLifeTime godoc
-// Vérifie les incidents en cours qui ont dépassé leur durée de vie maximale
+// Vérifie les incidents en cours qui ont dépassé leur durée de vie maximale, ajustée selon leur gravité
 func (s *Scheduler) CheckGlobalLifeTime(ctx context.Context, exec *bun.Tx) {
 	incidents, err := s.incidents.GetAllActive(ctx, exec)
 	if err != nil {
@@ -53,8 +53,8 @@ func (s *Scheduler) CheckGlobalLifeTime(ctx context.Context, exec *bun.Tx) {
 	}
 
 	for _, incident := range incidents {
-		incidentTTL := time.Duration(incident.Type.GlobalLifetime)
-		if time.Since(incident.CreatedAt) > incidentTTL*time.Second {
+		incidentTTL := incident.SeverityLifetime(incident.Type.GlobalLifetime, s.config.SeverityLifetimeFactors)
+		if time.Since(incident.CreatedAt) > incidentTTL {
 			now := time.Now()
 			incident.DeletedAt = &now
 
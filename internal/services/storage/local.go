@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Local enregistre les fichiers dans un répertoire du système de fichiers
+type Local struct {
+	dir string
+}
+
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create photos directory: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+func (l *Local) Put(_ context.Context, key string, content []byte, _ string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	// Écrit dans un fichier temporaire pour ne jamais exposer un fichier incomplet
+	tmp, err := os.CreateTemp(l.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (l *Local) Get(_ context.Context, key string) ([]byte, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+func (l *Local) Delete(_ context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path refuse les clés qui sortiraient du répertoire de stockage
+func (l *Local) path(key string) (string, error) {
+	if key == "" || !filepath.IsLocal(key) || filepath.Base(key) != key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(l.dir, key), nil
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// S3 enregistre les fichiers dans un bucket compatible S3 (AWS, MinIO, Garage...). Les requêtes utilisent
+// l'adressage par chemin (endpoint/bucket/clé) et sont signées avec AWS Signature Version 4.
+type S3 struct {
+	client    *http.Client
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+}
+
+func NewS3(endpoint string, region string, bucket string, accessKey string, secretKey string, timeout time.Duration) *S3 {
+	return &S3{
+		client:    &http.Client{Timeout: timeout},
+		endpoint:  strings.TrimSuffix(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+	}
+}
+
+func (s *S3) Put(ctx context.Context, key string, content []byte, contentType string) error {
+	res, err := s.do(ctx, http.MethodPut, key, content, contentType)
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d returned by S3 when uploading %s", res.StatusCode, key)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) ([]byte, error) {
+	res, err := s.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(res.Body)
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return io.ReadAll(res.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	}
+	return nil, fmt.Errorf("status code %d returned by S3 when downloading %s", res.StatusCode, key)
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	res, err := s.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer closeBody(res.Body)
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("status code %d returned by S3 when deleting %s", res.StatusCode, key)
+	}
+	return nil
+}
+
+func (s *S3) do(ctx context.Context, method string, key string, content []byte, contentType string) (*http.Response, error) {
+	objectUrl := fmt.Sprintf("%s/%s/%s", s.endpoint, url.PathEscape(s.bucket), url.PathEscape(key))
+	req, err := http.NewRequestWithContext(ctx, method, objectUrl, bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	s.sign(req, content, time.Now().UTC())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call S3: %w", err)
+	}
+	return res, nil
+}
+
+// sign ajoute la signature AWS Signature Version 4 à la requête
+func (s *S3) sign(req *http.Request, content []byte, now time.Time) {
+	date := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	payloadHash := sha256Hex(content)
+
+	req.Header.Set("X-Amz-Date", timestamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", req.URL.Host, payloadHash, timestamp)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", date, s.region)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", timestamp, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery trie les paramètres de la requête et les encode comme l'attend la signature,
+// un paramètre sans valeur étant écrit "nom="
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params, uriEncode(name)+"="+uriEncode(value))
+		}
+	}
+	sort.Strings(params)
+	return strings.Join(params, "&")
+}
+
+// uriEncode encode une valeur selon la RFC 3986, les espaces étant encodés "%20" et non "+"
+func uriEncode(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func closeBody(body io.ReadCloser) {
+	_, _ = io.Copy(io.Discard, body)
+	_ = body.Close()
+}
@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"supmap-users/internal/config"
+)
+
+// Backends de stockage des fichiers
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// ErrNotFound est retournée lorsque le fichier demandé n'existe pas
+var ErrNotFound = errors.New("blob not found")
+
+// Storage enregistre les fichiers envoyés par les utilisateurs, identifiés par une clé
+type Storage interface {
+	Put(ctx context.Context, key string, content []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// New crée le stockage configuré par PHOTOS_STORAGE
+func New(config *config.Config) (Storage, error) {
+	switch config.PhotosStorage {
+	case BackendLocal:
+		return NewLocal(config.PhotosDir)
+	case BackendS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" {
+			return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required with the s3 photos storage")
+		}
+		return NewS3(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3AccessKey, config.S3SecretKey, config.S3Timeout), nil
+	}
+	return nil, fmt.Errorf("unknown photos storage %q", config.PhotosStorage)
+}
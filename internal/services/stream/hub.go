@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/services/redis"
+	"sync"
+	"time"
+)
+
+// subscriptionBuffer est le nombre d'événements en attente pour un abonné
+// au-delà duquel il est considéré comme trop lent et déconnecté
+const subscriptionBuffer = 64
+
+// Event est un message d'incident reçu de Redis, numéroté pour permettre la reprise d'un flux
+type Event struct {
+	ID      uint64
+	Message redis.IncidentMessage
+}
+
+// Subscription reçoit les événements des incidents situés dans sa zone.
+// Le canal Events est fermé lorsque l'abonné est déconnecté par le Hub.
+type Subscription struct {
+	Events chan Event
+	area   helpers.Area
+}
+
+func (s *Subscription) accepts(event *Event) bool {
+	data := event.Message.Data
+	return s.area.Contains(data.Latitude, data.Longitude)
+}
+
+// Hub diffuse les messages d'incidents publiés dans Redis aux clients abonnés,
+// et conserve les derniers événements pour la reprise après déconnexion
+type Hub struct {
+	log    *slog.Logger
+	config *config.Config
+	redis  *redis.Redis
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	history     []Event
+	lastID      uint64
+}
+
+func NewHub(config *config.Config, redis *redis.Redis, log *slog.Logger) *Hub {
+	return &Hub{
+		log:         log,
+		config:      config,
+		redis:       redis,
+		subscribers: make(map[*Subscription]struct{}),
+		// Les identifiants partent de l'heure de démarrage pour rester croissants
+		// d'un redémarrage à l'autre, un client ne peut donc pas reprendre sur un
+		// identifiant attribué par une instance précédente
+		lastID: uint64(time.Now().UnixMicro()),
+	}
+}
+
+func (h *Hub) Run(ctx context.Context) {
+	messages := h.redis.Subscribe(ctx, h.config.IncidentChannel)
+	go func() {
+		for msg := range messages {
+			var message redis.IncidentMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				h.log.Error("failed to decode incident message", "error", err)
+				continue
+			}
+			h.broadcast(message)
+		}
+		h.log.Info("stopping incidents stream hub")
+	}()
+}
+
+func (h *Hub) broadcast(message redis.IncidentMessage) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{ID: h.lastID, Message: message}
+
+	h.history = append(h.history, event)
+	if overflow := len(h.history) - h.config.StreamHistorySize; overflow > 0 {
+		h.history = h.history[overflow:]
+	}
+
+	for sub := range h.subscribers {
+		if !sub.accepts(&event) {
+			continue
+		}
+		select {
+		case sub.Events <- event:
+		default:
+			// Le client ne consomme pas assez vite, on le déconnecte plutôt que de
+			// bloquer la diffusion. Il pourra reprendre grâce au dernier identifiant reçu.
+			h.log.Warn("incidents stream subscriber is too slow, disconnecting it")
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe abonne un client aux événements de la zone. Si lastEventID est fourni, les
+// événements manqués encore en mémoire sont retournés. complete est faux lorsque certains
+// événements manqués ne sont plus disponibles, le client doit alors recharger ses données.
+func (h *Hub) Subscribe(area helpers.Area, lastEventID *uint64) (sub *Subscription, missed []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub = &Subscription{
+		Events: make(chan Event, subscriptionBuffer),
+		area:   area,
+	}
+	h.subscribers[sub] = struct{}{}
+
+	if lastEventID == nil {
+		return sub, nil, true
+	}
+
+	oldest := h.lastID + 1
+	if len(h.history) > 0 {
+		oldest = h.history[0].ID
+	}
+	complete = *lastEventID+1 >= oldest && *lastEventID <= h.lastID
+
+	for _, event := range h.history {
+		if event.ID > *lastEventID && sub.accepts(&event) {
+			missed = append(missed, event)
+		}
+	}
+
+	return sub, missed, complete
+}
+
+// UpdateArea remplace la zone d'un abonnement, les événements suivants sont filtrés selon la nouvelle zone
+func (h *Hub) UpdateArea(sub *Subscription, area helpers.Area) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.area = area
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.Events)
+	}
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"time"
+)
+
+// syncOverlap recule le jeton retourné en fin de synchronisation. Les dates de mise à jour
+// sont fixées avant la validation des transactions et par plusieurs instances : une
+// modification validée tardivement serait manquée sans ce recouvrement. Les incidents
+// de cette période sont renvoyés au client lors de la synchronisation suivante.
+const syncOverlap = 10 * time.Second
+
+// IncidentChanges regroupe les incidents d'une zone modifiés depuis un jeton de synchronisation
+type IncidentChanges struct {
+	Created []models.Incident
+	Updated []models.Incident
+	Expired []models.Incident
+	Next    helpers.SyncToken
+	HasMore bool
+}
+
+// GetIncidentChanges godoc
+// Récupère les incidents de la zone créés, modifiés ou expirés depuis le jeton.
+// Sans jeton, tous les incidents en cours de la zone sont retournés comme créés.
+// Le nombre d'incidents est plafonné, HasMore indique qu'il faut rappeler avec le nouveau jeton.
+func (s *Service) GetIncidentChanges(ctx context.Context, box *helpers.BoundingBox, token string) (*IncidentChanges, error) {
+	if err := box.Validate(); err != nil {
+		return nil, &ErrorWithCode{
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	var since *helpers.SyncToken
+	if token != "" {
+		var err error
+		if since, err = helpers.DecodeSyncToken(token); err != nil {
+			return nil, &ErrorWithCode{
+				Message: err.Error(),
+				Code:    http.StatusBadRequest,
+			}
+		}
+	}
+
+	// La date de fin est fixée avant la requête pour ne pas dépasser les modifications lues
+	watermark := time.Now().Add(-syncOverlap)
+
+	incidents, err := s.incidents.FindIncidentChanges(ctx, box, since, s.config.SyncMaxResults+1)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &IncidentChanges{
+		Created: []models.Incident{},
+		Updated: []models.Incident{},
+		Expired: []models.Incident{},
+		Next:    helpers.SyncToken{UpdatedAt: watermark},
+	}
+
+	if len(incidents) > s.config.SyncMaxResults {
+		incidents = incidents[:s.config.SyncMaxResults]
+		last := incidents[len(incidents)-1]
+		changes.Next = helpers.SyncToken{UpdatedAt: last.UpdatedAt, ID: last.ID}
+		changes.HasMore = true
+	} else if since != nil && since.UpdatedAt.After(watermark) {
+		// Ne pas revenir avant le jeton reçu si le client synchronise plus souvent que syncOverlap
+		changes.Next = *since
+	}
+
+	for _, incident := range incidents {
+		switch {
+		case !incident.IsActive():
+			changes.Expired = append(changes.Expired, incident)
+		case since == nil || incident.CreatedAt.After(since.UpdatedAt):
+			changes.Created = append(changes.Created, incident)
+		default:
+			changes.Updated = append(changes.Updated, incident)
+		}
+	}
+
+	return changes, nil
+}
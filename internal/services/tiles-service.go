@@ -0,0 +1,31 @@
+package services
+
+import (
+	"context"
+	"github.com/paulmach/orb/maptile"
+	"net/http"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"supmap-users/internal/services/tiles"
+)
+
+// GetIncidentsTile godoc
+// Récupère les incidents en cours de la tuile et de sa marge, au plus TILES_MAX_FEATURES, les plus récents en priorité
+func (s *Service) GetIncidentsTile(ctx context.Context, tile maptile.Tile) ([]models.Incident, error) {
+	if tile.Z > tiles.MaxZoom || !tile.Valid() {
+		return nil, &ErrorWithCode{
+			Message: "invalid tile coordinates",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	bound := tile.Bound(tiles.Buffer)
+	box := &helpers.BoundingBox{
+		MinLat: bound.Min.Lat(),
+		MinLon: max(-180, bound.Min.Lon()),
+		MaxLat: bound.Max.Lat(),
+		MaxLon: min(180, bound.Max.Lon()),
+	}
+
+	return s.incidents.FindIncidentsInBoundingBox(ctx, box, nil, s.config.TilesMaxFeatures)
+}
@@ -0,0 +1,153 @@
+package tiles
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"golang.org/x/sync/singleflight"
+	"log/slog"
+	"math"
+	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/services/redis"
+	"sync"
+)
+
+// Buffer est la marge autour des tuiles, en fraction de tuile, dans laquelle les incidents
+// sont aussi inclus pour que les symboles situés en bordure ne soient pas coupés
+const Buffer = 1.0 / 64
+
+// MaxZoom est le niveau de zoom maximal des tuiles servies
+const MaxZoom = 22
+
+// Cache conserve les tuiles encodées pendant TILES_CACHE_TTL, dans la limite de TILES_CACHE_SIZE tuiles
+// (les moins récemment servies sont retirées en premier). Les tuiles contenant un incident créé, certifié,
+// supprimé ou modifié sont invalidées à la réception de l'événement publié dans Redis, et toutes les
+// tuiles sont invalidées lors d'une modification des types d'incidents.
+type Cache struct {
+	log    *slog.Logger
+	config *config.Config
+	redis  *redis.Redis
+	// loads regroupe les encodages simultanés d'une même tuile en une seule requête
+	loads singleflight.Group
+
+	mu    sync.Mutex
+	tiles *helpers.TTLMap[maptile.Tile, []byte]
+	// generation est incrémentée à chaque invalidation, une tuile encodée
+	// avant une invalidation n'est alors pas mise en cache
+	generation uint64
+}
+
+func NewCache(config *config.Config, redis *redis.Redis, log *slog.Logger) *Cache {
+	return &Cache{
+		log:    log,
+		config: config,
+		redis:  redis,
+		tiles:  helpers.NewTTLMap[maptile.Tile, []byte](config.TilesCacheSize, nil),
+	}
+}
+
+func (c *Cache) Run(ctx context.Context) {
+	incidents := c.redis.Subscribe(ctx, c.config.IncidentChannel)
+	go func() {
+		for msg := range incidents {
+			var message redis.IncidentMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				c.log.Error("failed to decode incident message", "error", err)
+				continue
+			}
+			c.Invalidate(message.Data.Latitude, message.Data.Longitude)
+		}
+		c.log.Info("stopping tiles cache invalidation")
+	}()
+
+	types := c.redis.Subscribe(ctx, c.config.TypesChannel)
+	go func() {
+		for range types {
+			c.Flush()
+		}
+	}()
+}
+
+// Load retourne la tuile depuis le cache, ou l'encode avec load puis la conserve. Les demandes
+// simultanées d'une tuile absente du cache attendent le résultat d'un seul appel à load.
+func (c *Cache) Load(tile maptile.Tile, load func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	cached, ok := c.tiles.Get(tile)
+	generation := c.generation
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+
+	// La génération fait partie de la clé : une demande postérieure à une invalidation
+	// n'attend pas un encodage commencé avant celle-ci
+	key := fmt.Sprintf("%d/%d/%d@%d", tile.Z, tile.X, tile.Y, generation)
+	content, err, _ := c.loads.Do(key, func() (any, error) {
+		content, err := load()
+		if err != nil {
+			return nil, err
+		}
+		c.store(tile, content, generation)
+		return content, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return content.([]byte), nil
+}
+
+// Invalidate retire du cache les tuiles de tous les niveaux de zoom dont la zone, marge comprise,
+// contient le point
+func (c *Cache) Invalidate(lat float64, lon float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	point := orb.Point{lon, lat}
+	for z := maptile.Zoom(0); z <= MaxZoom; z++ {
+		fraction := maptile.Fraction(point, z)
+		last := float64(uint32(1)<<z - 1)
+		minX, maxX := tileRange(fraction.X(), last)
+		minY, maxY := tileRange(fraction.Y(), last)
+		for x := minX; x <= maxX; x++ {
+			for y := minY; y <= maxY; y++ {
+				c.tiles.Delete(maptile.New(x, y, z))
+			}
+		}
+	}
+}
+
+// Flush vide le cache
+func (c *Cache) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.tiles.Clear()
+	c.log.Info("tiles cache flushed")
+}
+
+func (c *Cache) store(tile maptile.Tile, content []byte, generation uint64) {
+	if c.config.TilesCacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.tiles.Set(tile, content, c.config.TilesCacheTTL)
+}
+
+// tileRange retourne les indices des tuiles dont la zone, marge comprise, contient la coordonnée fractionnaire
+func tileRange(fraction float64, last float64) (uint32, uint32) {
+	first := math.Max(0, math.Floor(fraction-Buffer))
+	end := math.Min(last, math.Floor(fraction+Buffer))
+	return uint32(first), uint32(math.Max(first, end))
+}
@@ -0,0 +1,208 @@
+package tiles
+
+import (
+	"errors"
+	"github.com/paulmach/orb"
+	"github.com/paulmach/orb/maptile"
+	"io"
+	"log/slog"
+	"supmap-users/internal/config"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestCache(size int) *Cache {
+	conf := &config.Config{TilesCacheTTL: time.Minute, TilesCacheSize: size}
+	return NewCache(conf, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestTileRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		fraction  float64
+		last      float64
+		wantFirst uint32
+		wantLast  uint32
+	}{
+		{"middle of a tile", 5.5, 15, 5, 5},
+		{"left edge", 5, 15, 4, 5},
+		{"inside the left buffer", 5 + Buffer/2, 15, 4, 5},
+		{"just past the left buffer", 5 + 2*Buffer, 15, 5, 5},
+		{"inside the right buffer", 6 - Buffer/2, 15, 5, 6},
+		{"just before the right buffer", 6 - 2*Buffer, 15, 5, 5},
+		{"first tile of the world", 0, 15, 0, 0},
+		{"last tile of the world", 16 - Buffer/2, 15, 15, 15},
+		{"world edge", 16, 15, 15, 15},
+		{"single tile at zoom 0", 0.5, 0, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last := tileRange(tt.fraction, tt.last)
+			if first != tt.wantFirst || last != tt.wantLast {
+				t.Errorf("tileRange(%v, %v) = (%d, %d), want (%d, %d)", tt.fraction, tt.last, first, last, tt.wantFirst, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestInvalidate(t *testing.T) {
+	const z = 10
+
+	tests := []struct {
+		name        string
+		point       orb.Point
+		invalidated []maptile.Tile
+		kept        []maptile.Tile
+	}{
+		{
+			name:        "tile center",
+			point:       maptile.New(300, 400, z).Center(),
+			invalidated: []maptile.Tile{maptile.New(300, 400, z), maptile.New(150, 200, z-1)},
+			kept:        []maptile.Tile{maptile.New(299, 400, z), maptile.New(301, 400, z), maptile.New(300, 401, z)},
+		},
+		{
+			name:  "tile corner",
+			point: maptile.New(300, 400, z).Bound().Min,
+			invalidated: []maptile.Tile{
+				maptile.New(299, 400, z), maptile.New(300, 400, z),
+				maptile.New(299, 401, z), maptile.New(300, 401, z),
+			},
+			kept: []maptile.Tile{maptile.New(301, 400, z), maptile.New(298, 400, z)},
+		},
+		{
+			name:        "world origin",
+			point:       orb.Point{0, 0},
+			invalidated: []maptile.Tile{maptile.New(0, 0, 0), maptile.New(0, 0, 1), maptile.New(1, 1, 1), maptile.New(511, 511, z), maptile.New(512, 512, z)},
+			kept:        []maptile.Tile{maptile.New(510, 511, z), maptile.New(513, 512, z)},
+		},
+		{
+			name:        "antimeridian",
+			point:       orb.Point{180, 0},
+			invalidated: []maptile.Tile{maptile.New(1023, 511, z), maptile.New(1023, 512, z)},
+			kept:        []maptile.Tile{maptile.New(0, 511, z), maptile.New(1022, 511, z)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(0)
+			for _, tile := range append(tt.invalidated, tt.kept...) {
+				c.tiles.Set(tile, []byte("tile"), time.Minute)
+			}
+
+			c.Invalidate(tt.point.Lat(), tt.point.Lon())
+
+			for _, tile := range tt.invalidated {
+				if _, ok := c.tiles.Get(tile); ok {
+					t.Errorf("expected tile %v to be invalidated", tile)
+				}
+			}
+			for _, tile := range tt.kept {
+				if _, ok := c.tiles.Get(tile); !ok {
+					t.Errorf("expected tile %v to be kept", tile)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidateBuffer(t *testing.T) {
+	const z = 12
+	tile := maptile.New(1000, 1000, z)
+	bound := tile.Bound()
+	// Largeur de la marge en longitude, l'axe X étant linéaire en longitude
+	buffer := (bound.Max.Lon() - bound.Min.Lon()) * Buffer
+
+	tests := []struct {
+		name        string
+		lon         float64
+		invalidated bool
+	}{
+		{"inside the buffer", bound.Min.Lon() - buffer/2, true},
+		{"outside the buffer", bound.Min.Lon() - 2*buffer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestCache(0)
+			c.tiles.Set(tile, []byte("tile"), time.Minute)
+
+			c.Invalidate(tile.Center().Lat(), tt.lon)
+
+			if _, ok := c.tiles.Get(tile); ok == tt.invalidated {
+				t.Errorf("expected invalidated=%t for longitude %v", tt.invalidated, tt.lon)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tile := maptile.New(1, 2, 3)
+
+	t.Run("concurrent misses share one load", func(t *testing.T) {
+		c := newTestCache(0)
+		var calls atomic.Int32
+		release := make(chan struct{})
+
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				content, err := c.Load(tile, func() ([]byte, error) {
+					calls.Add(1)
+					<-release
+					return []byte("tile"), nil
+				})
+				if err != nil || string(content) != "tile" {
+					t.Errorf("unexpected result %q, %v", content, err)
+				}
+			}()
+		}
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Errorf("expected a single load, got %d", calls.Load())
+		}
+	})
+
+	t.Run("errors are not cached", func(t *testing.T) {
+		c := newTestCache(0)
+		if _, err := c.Load(tile, func() ([]byte, error) { return nil, errors.New("failed") }); err == nil {
+			t.Fatal("expected load error")
+		}
+		content, err := c.Load(tile, func() ([]byte, error) { return []byte("tile"), nil })
+		if err != nil || string(content) != "tile" {
+			t.Errorf("unexpected result %q, %v", content, err)
+		}
+	})
+
+	t.Run("tile loaded before an invalidation is not cached", func(t *testing.T) {
+		c := newTestCache(0)
+		_, _ = c.Load(tile, func() ([]byte, error) {
+			c.Flush()
+			return []byte("stale"), nil
+		})
+		content, _ := c.Load(tile, func() ([]byte, error) { return []byte("fresh"), nil })
+		if string(content) != "fresh" {
+			t.Errorf("expected fresh tile, got %q", content)
+		}
+	})
+
+	t.Run("size cap", func(t *testing.T) {
+		c := newTestCache(2)
+		for x := range uint32(3) {
+			_, _ = c.Load(maptile.New(x, 0, 3), func() ([]byte, error) { return []byte("tile"), nil })
+		}
+		if _, ok := c.tiles.Get(maptile.New(0, 0, 3)); ok {
+			t.Error("expected least recently used tile to be evicted")
+		}
+		if _, ok := c.tiles.Get(maptile.New(2, 0, 3)); !ok {
+			t.Error("expected last tile to be cached")
+		}
+	})
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"supmap-users/internal/api/validations"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/redis"
+	"time"
+)
+
+func (s *Service) CreateIncidentType(ctx context.Context, body *validations.CreateTypeValidator) (*models.Type, error) {
+	schema, err := checkAttributesSchema(body.AttributesSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	incidentType := &models.Type{
+		Name:                        body.Name,
+		Description:                 body.Description,
+		LifetimeWithoutConfirmation: body.LifetimeWithoutConfirmation,
+		NegativeReportsThreshold:    body.NegativeReportsThreshold,
+		GlobalLifetime:              body.GlobalLifetime,
+		PositiveReportsThreshold:    body.PositiveReportsThreshold,
+		NeedRecalculation:           *body.NeedRecalculation,
+		AttributesSchema:            body.AttributesSchema,
+	}
+
+	if err := s.incidents.CreateIncidentType(ctx, incidentType); err != nil {
+		return nil, err
+	}
+	s.schemas.set(incidentType.ID, incidentType.AttributesSchema, schema)
+
+	if err := s.publishType(incidentType, redis.Create); err != nil {
+		return nil, err
+	}
+
+	return incidentType, nil
+}
+
+// UpdateIncidentTypeSettings godoc
+// Met à jour les champs fournis d'un type d'incident. Les seuils sont pris en compte
+// dès la prochaine exécution de l'auto-modération, y compris pour les incidents en cours.
+func (s *Service) UpdateIncidentTypeSettings(ctx context.Context, id int64, body *validations.UpdateTypeValidator) (*models.Type, error) {
+	incidentType, err := s.FindTypeById(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if body.Name != nil {
+		incidentType.Name = *body.Name
+	}
+	if body.Description != nil {
+		incidentType.Description = *body.Description
+	}
+	if body.LifetimeWithoutConfirmation != nil {
+		incidentType.LifetimeWithoutConfirmation = *body.LifetimeWithoutConfirmation
+	}
+	if body.NegativeReportsThreshold != nil {
+		incidentType.NegativeReportsThreshold = *body.NegativeReportsThreshold
+	}
+	if body.GlobalLifetime != nil {
+		incidentType.GlobalLifetime = *body.GlobalLifetime
+	}
+	if body.PositiveReportsThreshold != nil {
+		incidentType.PositiveReportsThreshold = *body.PositiveReportsThreshold
+	}
+	if body.NeedRecalculation != nil {
+		incidentType.NeedRecalculation = *body.NeedRecalculation
+	}
+	var schema *helpers.JSONSchema
+	if body.AttributesSchema.Set {
+		if schema, err = checkAttributesSchema(body.AttributesSchema.Value); err != nil {
+			return nil, err
+		}
+		incidentType.AttributesSchema = body.AttributesSchema.Value
+	}
+	wasDisabled := incidentType.DisabledAt != nil
+	if body.Disabled != nil {
+		if !*body.Disabled {
+			incidentType.DisabledAt = nil
+		} else if incidentType.DisabledAt == nil {
+			incidentType.DisabledAt = toPtr(time.Now())
+		}
+	}
+
+	if incidentType.GlobalLifetime < incidentType.LifetimeWithoutConfirmation {
+		return nil, &ErrorWithCode{
+			Message: "Global lifetime must be greater than or equal to lifetime without confirmation",
+			Code:    http.StatusBadRequest,
+		}
+	}
+
+	if err := s.incidents.UpdateIncidentType(ctx, incidentType); err != nil {
+		return nil, err
+	}
+	if body.AttributesSchema.Set {
+		s.schemas.set(incidentType.ID, incidentType.AttributesSchema, schema)
+	}
+
+	action := typeSettingsAction(wasDisabled, incidentType.DisabledAt != nil)
+	if err := s.publishType(incidentType, action); err != nil {
+		return nil, err
+	}
+
+	return incidentType, nil
+}
+
+// typeSettingsAction retourne l'action publiée après la modification d'un type : deleted lorsqu'il vient
+// d'être désactivé, restored lorsqu'il vient d'être réactivé pour que les clients l'ajoutent à nouveau
+// à leur cache, updated sinon, y compris pour un type qui reste désactivé
+func typeSettingsAction(wasDisabled bool, disabled bool) redis.Action {
+	switch {
+	case !wasDisabled && disabled:
+		return redis.Deleted
+	case wasDisabled && !disabled:
+		return redis.Restored
+	default:
+		return redis.Updated
+	}
+}
+
+// DisableIncidentType godoc
+// Désactive un type d'incident : il ne peut plus être signalé mais les incidents existants le conservent
+func (s *Service) DisableIncidentType(ctx context.Context, id int64) error {
+	incidentType, err := s.FindTypeById(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if incidentType.DisabledAt != nil {
+		return nil
+	}
+
+	incidentType.DisabledAt = toPtr(time.Now())
+	if err := s.incidents.UpdateIncidentType(ctx, incidentType); err != nil {
+		return err
+	}
+
+	return s.publishType(incidentType, redis.Deleted)
+}
+
+// checkAttributesSchema vérifie que le schéma des attributs, s'il est fourni, est utilisable pour la validation
+// et retourne le schéma compilé
+func checkAttributesSchema(schema map[string]any) (*helpers.JSONSchema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	compiled, err := helpers.CompileJSONSchema(schema)
+	if err != nil {
+		return nil, &ErrorWithCode{
+			Message: err.Error(),
+			Code:    http.StatusBadRequest,
+		}
+	}
+	return compiled, nil
+}
+
+func (s *Service) publishType(incidentType *models.Type, action redis.Action) error {
+	return s.redis.PublishMessage(s.config.TypesChannel, &redis.TypeMessage{
+		Data:   *dto.TypeToDTO(incidentType),
+		Action: action,
+	})
+}
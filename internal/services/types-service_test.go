@@ -0,0 +1,28 @@
+package services
+
+import (
+	"supmap-users/internal/services/redis"
+	"testing"
+)
+
+func TestTypeSettingsAction(t *testing.T) {
+	tests := []struct {
+		name        string
+		wasDisabled bool
+		disabled    bool
+		want        redis.Action
+	}{
+		{"enabled type updated", false, false, redis.Updated},
+		{"type disabled", false, true, redis.Deleted},
+		{"type enabled again", true, false, redis.Restored},
+		{"disabled type updated", true, true, redis.Updated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := typeSettingsAction(tt.wasDisabled, tt.disabled); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
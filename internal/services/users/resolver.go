@@ -0,0 +1,262 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"supmap-users/internal/config"
+	"supmap-users/internal/helpers"
+	"supmap-users/internal/models/dto"
+	"supmap-users/internal/services/redis"
+	"sync"
+	"time"
+)
+
+const (
+	// redisKeyPrefix préfixe les clés du cache partagé entre les instances
+	redisKeyPrefix = "incidents:users:"
+	// maxParallelLookups limite les requêtes unitaires simultanées vers le service users
+	maxParallelLookups = 8
+	// unavailableBackoff est la durée pendant laquelle le service users n'est plus sollicité après un échec
+	unavailableBackoff = 10 * time.Second
+)
+
+// Resolver récupère les utilisateurs auprès du service users. Les utilisateurs sont mis en cache
+// en mémoire, et optionnellement dans Redis, et les utilisateurs manquants d'une réponse sont
+// demandés en une seule requête.
+type Resolver struct {
+	log    *slog.Logger
+	config *config.Config
+	client *http.Client
+	redis  *redis.Redis
+
+	mu               sync.Mutex
+	cache            *helpers.TTLMap[int64, *dto.PartialUserDTO]
+	unavailableUntil time.Time
+	// bulkUnsupported est positionné dès que le service users refuse la recherche groupée
+	bulkUnsupported bool
+}
+
+func NewResolver(config *config.Config, redis *redis.Redis, log *slog.Logger) *Resolver {
+	resolver := &Resolver{
+		log:    log,
+		config: config,
+		client: &http.Client{Timeout: config.UsersTimeout},
+		cache:  helpers.NewTTLMap[int64, *dto.PartialUserDTO](0, nil),
+	}
+	if config.UsersCacheRedis {
+		resolver.redis = redis
+	}
+	return resolver
+}
+
+// Resolve retourne les utilisateurs correspondant aux IDs. Les utilisateurs qui n'ont pas pu être
+// récupérés sont absents du résultat, dto.Users.Get retourne alors un utilisateur réduit à son ID.
+func (r *Resolver) Resolve(ctx context.Context, ids dto.UserIDs) dto.Users {
+	users := make(dto.Users, len(ids))
+	missing := r.fromMemory(ids, users)
+
+	if len(missing) > 0 && r.redis != nil {
+		missing = r.fromRedis(ctx, missing, users)
+	}
+
+	if len(missing) > 0 {
+		fetched := r.fetch(ctx, missing)
+		for _, user := range fetched {
+			users[user.ID] = user
+		}
+		r.store(ctx, fetched)
+	}
+
+	return users
+}
+
+func (r *Resolver) fromMemory(ids dto.UserIDs, users dto.Users) []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var missing []int64
+	for id := range ids {
+		if cached, ok := r.cache.Get(id); ok {
+			users[id] = cached
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func (r *Resolver) fromRedis(ctx context.Context, ids []int64, users dto.Users) []int64 {
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = redisKeyPrefix + strconv.FormatInt(id, 10)
+	}
+
+	values, err := r.redis.GetValues(ctx, keys...)
+	if err != nil {
+		r.log.Warn("failed to read users from redis cache", "error", err)
+		return ids
+	}
+
+	var missing []int64
+	var found []*dto.PartialUserDTO
+	for i, value := range values {
+		var user dto.PartialUserDTO
+		if value == nil || json.Unmarshal([]byte(*value), &user) != nil {
+			missing = append(missing, ids[i])
+			continue
+		}
+		users[user.ID] = &user
+		found = append(found, &user)
+	}
+
+	r.storeInMemory(found)
+	return missing
+}
+
+func (r *Resolver) store(ctx context.Context, users []*dto.PartialUserDTO) {
+	r.storeInMemory(users)
+
+	if r.redis == nil {
+		return
+	}
+	for _, user := range users {
+		payload, err := json.Marshal(user)
+		if err != nil {
+			continue
+		}
+		key := redisKeyPrefix + strconv.FormatInt(user.ID, 10)
+		if err := r.redis.SetValue(ctx, key, string(payload), r.config.UsersCacheTTL); err != nil {
+			r.log.Warn("failed to write user to redis cache", "error", err)
+			return
+		}
+	}
+}
+
+func (r *Resolver) storeInMemory(users []*dto.PartialUserDTO) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, user := range users {
+		r.cache.Set(user.ID, user, r.config.UsersCacheTTL)
+	}
+}
+
+// fetch demande les utilisateurs au service users en une seule requête. Si le service ne propose
+// pas la recherche groupée (code 404, 405 ou 501), ce qui n'est constaté qu'une fois, les utilisateurs
+// sont demandés un par un, en parallèle. Après un autre échec, le service n'est plus sollicité pendant
+// unavailableBackoff.
+func (r *Resolver) fetch(ctx context.Context, ids []int64) []*dto.PartialUserDTO {
+	r.mu.Lock()
+	unavailable := time.Now().Before(r.unavailableUntil)
+	bulkUnsupported := r.bulkUnsupported
+	r.mu.Unlock()
+	if unavailable {
+		return nil
+	}
+
+	if !bulkUnsupported {
+		users, err := r.fetchBulk(ctx, ids)
+		switch {
+		case err == nil:
+			return users
+		case errors.Is(err, errBulkUnsupported):
+			r.log.Warn("users service does not support bulk lookup, users are now fetched one by one", "error", err)
+			r.mu.Lock()
+			r.bulkUnsupported = true
+			r.mu.Unlock()
+		case ctx.Err() != nil:
+			// Une requête annulée par l'appelant ne dit rien de la disponibilité du service
+			return nil
+		default:
+			// Évite d'attendre le délai d'expiration à chaque requête tant que le service est indisponible
+			r.log.Error("failed to fetch users, users service is considered unavailable", "error", err, "backoff", unavailableBackoff)
+			r.mu.Lock()
+			r.unavailableUntil = time.Now().Add(unavailableBackoff)
+			r.mu.Unlock()
+			return nil
+		}
+	}
+
+	var users []*dto.PartialUserDTO
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, maxParallelLookups)
+	for _, id := range ids {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(id int64) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			user, err := r.fetchOne(ctx, id)
+			if err != nil {
+				r.log.Error("failed to fetch user", "user", id, "error", err)
+				return
+			}
+			mu.Lock()
+			users = append(users, user)
+			mu.Unlock()
+		}(id)
+	}
+	wg.Wait()
+
+	return users
+}
+
+var errBulkUnsupported = errors.New("users service does not support bulk lookup")
+
+func (r *Resolver) fetchBulk(ctx context.Context, ids []int64) ([]*dto.PartialUserDTO, error) {
+	values := make([]string, len(ids))
+	for i, id := range ids {
+		values[i] = strconv.FormatInt(id, 10)
+	}
+	query := url.Values{"ids": {strings.Join(values, ",")}}
+
+	var users []*dto.PartialUserDTO
+	status, err := r.get(ctx, fmt.Sprintf("%s/internal/users/bulk?%s", config.UsersBaseUrl, query.Encode()), &users)
+	switch status {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		// Seules ces réponses indiquent une route absente, une erreur serveur peut n'être que passagère
+		return nil, fmt.Errorf("%w: %w", errBulkUnsupported, err)
+	}
+	return users, err
+}
+
+func (r *Resolver) fetchOne(ctx context.Context, id int64) (*dto.PartialUserDTO, error) {
+	var user dto.PartialUserDTO
+	if _, err := r.get(ctx, fmt.Sprintf("%s/internal/users/%d", config.UsersBaseUrl, id), &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *Resolver) get(ctx context.Context, endpoint string, body any) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func(Body io.ReadCloser) {
+		if err := Body.Close(); err != nil {
+			r.log.Warn("failed to close response body", "error", err)
+		}
+	}(res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("status code %d returned by users service", res.StatusCode)
+	}
+
+	return res.StatusCode, json.NewDecoder(res.Body).Decode(body)
+}
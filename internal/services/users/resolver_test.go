@@ -0,0 +1,182 @@
+package users
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"supmap-users/internal/config"
+	"supmap-users/internal/models/dto"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// usersService simule le service users et compte les requêtes reçues
+type usersService struct {
+	bulkStatus int
+	bulk       atomic.Int32
+	single     atomic.Int32
+}
+
+func (u *usersService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/internal/users/bulk" {
+		u.bulk.Add(1)
+		if u.bulkStatus != http.StatusOK {
+			w.WriteHeader(u.bulkStatus)
+			return
+		}
+		var users []dto.PartialUserDTO
+		for _, value := range strings.Split(r.URL.Query().Get("ids"), ",") {
+			id, _ := strconv.ParseInt(value, 10, 64)
+			users = append(users, dto.PartialUserDTO{ID: id, Handle: fmt.Sprintf("user-%d", id)})
+		}
+		_ = json.NewEncoder(w).Encode(users)
+		return
+	}
+
+	u.single.Add(1)
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/internal/users/"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(dto.PartialUserDTO{ID: id, Handle: fmt.Sprintf("user-%d", id)})
+}
+
+func newTestResolver(t *testing.T, handler http.Handler) (*Resolver, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	previous := config.UsersBaseUrl
+	config.UsersBaseUrl = server.URL
+	t.Cleanup(func() { config.UsersBaseUrl = previous })
+
+	conf := &config.Config{UsersTimeout: time.Second, UsersCacheTTL: time.Minute}
+	return NewResolver(conf, nil, slog.New(slog.NewTextHandler(io.Discard, nil))), server
+}
+
+func userIDs(ids ...int64) dto.UserIDs {
+	result := make(dto.UserIDs, len(ids))
+	for _, id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
+
+func TestResolveBulk(t *testing.T) {
+	service := &usersService{bulkStatus: http.StatusOK}
+	resolver, _ := newTestResolver(t, service)
+
+	users := resolver.Resolve(context.Background(), userIDs(1, 2, 3))
+	if len(users) != 3 || users.Get(2).Handle != "user-2" {
+		t.Fatalf("unexpected users %v", users)
+	}
+	if service.bulk.Load() != 1 || service.single.Load() != 0 {
+		t.Errorf("expected a single bulk request, got %d bulk and %d single", service.bulk.Load(), service.single.Load())
+	}
+
+	// Les utilisateurs sont ensuite servis depuis le cache
+	resolver.Resolve(context.Background(), userIDs(1, 2, 3))
+	if service.bulk.Load() != 1 {
+		t.Errorf("expected cached users, got %d bulk requests", service.bulk.Load())
+	}
+}
+
+func TestResolveBulkUnsupported(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			service := &usersService{bulkStatus: status}
+			resolver, _ := newTestResolver(t, service)
+
+			users := resolver.Resolve(context.Background(), userIDs(1, 2))
+			if len(users) != 2 || users.Get(1).Handle != "user-1" {
+				t.Fatalf("expected users fetched one by one, got %v", users)
+			}
+
+			// Le refus de la recherche groupée n'est constaté qu'une fois
+			users = resolver.Resolve(context.Background(), userIDs(3, 4))
+			if len(users) != 2 {
+				t.Fatalf("unexpected users %v", users)
+			}
+			if service.bulk.Load() != 1 {
+				t.Errorf("expected a single bulk request, got %d", service.bulk.Load())
+			}
+			if service.single.Load() != 4 {
+				t.Errorf("expected 4 single requests, got %d", service.single.Load())
+			}
+		})
+	}
+}
+
+func TestResolveUnavailableBackoff(t *testing.T) {
+	service := &usersService{bulkStatus: http.StatusOK}
+	resolver, server := newTestResolver(t, service)
+	server.Close()
+
+	if users := resolver.Resolve(context.Background(), userIDs(1)); len(users) != 0 {
+		t.Fatalf("expected no user, got %v", users)
+	}
+	if !time.Now().Before(resolver.unavailableUntil) {
+		t.Fatal("expected the users service to be considered unavailable")
+	}
+	if resolver.bulkUnsupported {
+		t.Error("a connection failure must not disable bulk lookup")
+	}
+}
+
+func TestResolveBulkServerError(t *testing.T) {
+	for _, status := range []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable} {
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			service := &usersService{bulkStatus: status}
+			resolver, _ := newTestResolver(t, service)
+
+			if users := resolver.Resolve(context.Background(), userIDs(1, 2)); len(users) != 0 {
+				t.Fatalf("expected no user, got %v", users)
+			}
+			if resolver.bulkUnsupported {
+				t.Error("a server error must not disable bulk lookup")
+			}
+			if !time.Now().Before(resolver.unavailableUntil) {
+				t.Error("expected the users service to be considered unavailable")
+			}
+			if service.single.Load() != 0 {
+				t.Errorf("expected no single request, got %d", service.single.Load())
+			}
+
+			// Une fois le délai écoulé, la recherche groupée est de nouveau utilisée
+			service.bulkStatus = http.StatusOK
+			resolver.unavailableUntil = time.Time{}
+			if users := resolver.Resolve(context.Background(), userIDs(1, 2)); len(users) != 2 {
+				t.Fatalf("expected users fetched in bulk, got %v", users)
+			}
+			if service.bulk.Load() != 2 || service.single.Load() != 0 {
+				t.Errorf("expected 2 bulk requests and no single request, got %d bulk and %d single", service.bulk.Load(), service.single.Load())
+			}
+		})
+	}
+}
+
+func TestResolveCanceledContextSkipsBackoff(t *testing.T) {
+	service := &usersService{bulkStatus: http.StatusOK}
+	resolver, _ := newTestResolver(t, service)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if users := resolver.Resolve(ctx, userIDs(1)); len(users) != 0 {
+		t.Fatalf("expected no user, got %v", users)
+	}
+	if !resolver.unavailableUntil.IsZero() {
+		t.Fatal("a canceled request must not mark the users service as unavailable")
+	}
+
+	// Les requêtes suivantes sollicitent à nouveau le service
+	if users := resolver.Resolve(context.Background(), userIDs(1)); len(users) != 1 {
+		t.Fatalf("expected the user to be resolved, got %v", users)
+	}
+}
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE incident_types ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE incident_types DROP COLUMN disabled_at;
+-- +goose StatementEnd
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Index pour la pagination de l'historique par utilisateur
+CREATE INDEX incidents_user_history_idx ON incidents (user_id, created_at DESC, id DESC)
+    WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS incidents_user_history_idx;
+-- +goose StatementEnd
@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Index pour la synchronisation différentielle des incidents modifiés
+CREATE INDEX incidents_updated_at_idx ON incidents (updated_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS incidents_updated_at_idx;
+-- +goose StatementEnd
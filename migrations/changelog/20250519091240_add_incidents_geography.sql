@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Colonne geography optionnelle : elle n'est créée que si l'extension PostGIS est disponible sur le serveur.
+-- Sans PostGIS, les recherches par rayon utilisent les colonnes latitude et longitude.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis') THEN
+        RAISE NOTICE 'postgis extension is not available, skipping geography column';
+        RETURN;
+    END IF;
+
+    BEGIN
+        CREATE EXTENSION IF NOT EXISTS postgis;
+    EXCEPTION WHEN insufficient_privilege THEN
+        RAISE NOTICE 'not allowed to create postgis extension, skipping geography column';
+        RETURN;
+    END;
+
+    -- Colonne générée, toujours synchronisée avec latitude et longitude
+    ALTER TABLE incidents ADD COLUMN IF NOT EXISTS geog geography(Point, 4326)
+        GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)::geography) STORED;
+    CREATE INDEX IF NOT EXISTS incidents_geog_idx ON incidents USING GIST (geog);
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS incidents_geog_idx;
+ALTER TABLE incidents DROP COLUMN IF EXISTS geog;
+-- +goose StatementEnd
@@ -0,0 +1,70 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE incidents ADD COLUMN geohash VARCHAR(12);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- Même algorithme que helpers.EncodeGeohash, utilisé uniquement pour initialiser les incidents existants
+CREATE FUNCTION pg_temp.geohash_encode(lat DOUBLE PRECISION, lon DOUBLE PRECISION, hash_length INT) RETURNS TEXT AS $$
+DECLARE
+    base32    CONSTANT TEXT := '0123456789bcdefghjkmnpqrstuvwxyz';
+    min_lat   DOUBLE PRECISION := -90;
+    max_lat   DOUBLE PRECISION := 90;
+    min_lon   DOUBLE PRECISION := -180;
+    max_lon   DOUBLE PRECISION := 180;
+    mid       DOUBLE PRECISION;
+    hash      TEXT := '';
+    bits      INT := 0;
+    bit_count INT := 0;
+    even      BOOLEAN := TRUE;
+BEGIN
+    WHILE length(hash) < hash_length LOOP
+        IF even THEN
+            mid := (min_lon + max_lon) / 2;
+            IF lon >= mid THEN
+                bits := bits * 2 + 1;
+                min_lon := mid;
+            ELSE
+                bits := bits * 2;
+                max_lon := mid;
+            END IF;
+        ELSE
+            mid := (min_lat + max_lat) / 2;
+            IF lat >= mid THEN
+                bits := bits * 2 + 1;
+                min_lat := mid;
+            ELSE
+                bits := bits * 2;
+                max_lat := mid;
+            END IF;
+        END IF;
+        even := NOT even;
+
+        bit_count := bit_count + 1;
+        IF bit_count = 5 THEN
+            hash := hash || substr(base32, bits + 1, 1);
+            bits := 0;
+            bit_count := 0;
+        END IF;
+    END LOOP;
+    RETURN hash;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE incidents SET geohash = pg_temp.geohash_encode(latitude, longitude, 9);
+ALTER TABLE incidents ALTER COLUMN geohash SET NOT NULL;
+DROP FUNCTION pg_temp.geohash_encode(DOUBLE PRECISION, DOUBLE PRECISION, INT);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+-- text_pattern_ops permet d'utiliser l'index pour les recherches par préfixe (geohash LIKE 'u09t%')
+CREATE INDEX incidents_geohash_idx ON incidents (geohash text_pattern_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS incidents_geohash_idx;
+ALTER TABLE incidents DROP COLUMN IF EXISTS geohash;
+-- +goose StatementEnd
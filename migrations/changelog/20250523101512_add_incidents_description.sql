@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Description libre saisie par l'auteur du signalement
+ALTER TABLE incidents ADD COLUMN description VARCHAR(280);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE incidents DROP COLUMN IF EXISTS description;
+-- +goose StatementEnd
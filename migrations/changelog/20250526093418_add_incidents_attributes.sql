@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Schéma JSON des attributs propres à chaque type d'incident, et attributs des incidents
+ALTER TABLE incident_types ADD COLUMN attributes_schema JSONB;
+ALTER TABLE incidents ADD COLUMN attributes JSONB;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE incidents DROP COLUMN IF EXISTS attributes;
+ALTER TABLE incident_types DROP COLUMN IF EXISTS attributes_schema;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Photos jointes aux signalements et aux interactions, les fichiers sont enregistrés dans le stockage configuré
+CREATE TABLE incident_photos
+(
+    id             SERIAL PRIMARY KEY,
+    incident_id    INTEGER     NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+    interaction_id INTEGER REFERENCES incident_interactions (id) ON DELETE CASCADE,
+    user_id        INTEGER     NOT NULL,
+    key            VARCHAR(64) NOT NULL UNIQUE,
+    thumbnail_key  VARCHAR(64) NOT NULL UNIQUE,
+    width          INTEGER     NOT NULL,
+    height         INTEGER     NOT NULL,
+    created_at     TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX incident_photos_incident_idx ON incident_photos (incident_id);
+CREATE INDEX incident_photos_interaction_idx ON incident_photos (interaction_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS incident_photos;
+-- +goose StatementEnd
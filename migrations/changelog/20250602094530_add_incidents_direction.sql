@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Sens de circulation concerné par l'incident : cap en degrés et indicateur des deux sens
+ALTER TABLE incidents ADD COLUMN heading DOUBLE PRECISION CHECK (heading >= 0 AND heading < 360);
+ALTER TABLE incidents ADD COLUMN both_directions BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE incidents DROP COLUMN IF EXISTS both_directions;
+ALTER TABLE incidents DROP COLUMN IF EXISTS heading;
+-- +goose StatementEnd
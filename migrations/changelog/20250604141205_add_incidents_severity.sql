@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Gravité des incidents et évolution de la gravité signalée par les interactions
+ALTER TABLE incidents ADD COLUMN severity VARCHAR(16) NOT NULL DEFAULT 'moderate'
+    CHECK (severity IN ('minor', 'moderate', 'major', 'blocking'));
+ALTER TABLE incident_interactions ADD COLUMN severity_change VARCHAR(8)
+    CHECK (severity_change IN ('worse', 'better'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE incident_interactions DROP COLUMN IF EXISTS severity_change;
+ALTER TABLE incidents DROP COLUMN IF EXISTS severity;
+-- +goose StatementEnd